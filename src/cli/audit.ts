@@ -0,0 +1,166 @@
+import { loadConfig } from "../config/loader.js";
+import {
+  readAuditLog,
+  getDefaultAuditLogPath,
+  type AuditEntry,
+} from "../llm/audit-log.js";
+import { toWorkspacePath } from "../core/egress-policy.js";
+import { matchGlob } from "../utils/glob-match.js";
+
+export interface AuditArgs {
+  config?: string;
+  log?: string;
+  since?: string;
+  until?: string;
+  provider?: string;
+  file?: string;
+  remoteOnly: boolean;
+  files: boolean;
+  json: boolean;
+}
+
+export interface AuditFileSummary {
+  filePath: string;
+  requests: number;
+  providers: string[];
+  firstSent: string;
+  lastSent: string;
+}
+
+/**
+ * Filter audit entries by time range, provider, path glob and locality.
+ */
+export function filterAuditEntries(
+  entries: AuditEntry[],
+  args: Pick<AuditArgs, "since" | "until" | "provider" | "file" | "remoteOnly">,
+  cwd: string,
+): AuditEntry[] {
+  const since = args.since ? Date.parse(args.since) : undefined;
+  const until = args.until ? Date.parse(args.until) : undefined;
+
+  return entries.filter((entry) => {
+    const time = Date.parse(entry.timestamp);
+    if (since !== undefined && time < since) return false;
+    if (until !== undefined && time > until) return false;
+    if (args.provider && entry.provider !== args.provider) return false;
+    if (args.remoteOnly && !entry.remote) return false;
+    if (args.file) {
+      const pattern = args.file;
+      const matches = getSentFiles(entry).some((filePath) =>
+        matchGlob(toWorkspacePath(filePath, cwd), pattern),
+      );
+      if (!matches) return false;
+    }
+    return true;
+  });
+}
+
+/**
+ * Group audit entries by the file whose content was sent.
+ */
+export function summarizeAuditFiles(
+  entries: AuditEntry[],
+): AuditFileSummary[] {
+  const byFile = new Map<string, AuditFileSummary>();
+
+  for (const entry of entries) {
+    for (const filePath of getSentFiles(entry)) {
+      addToSummary(byFile, filePath, entry);
+    }
+  }
+
+  return Array.from(byFile.values()).sort((a, b) =>
+    a.filePath.localeCompare(b.filePath),
+  );
+}
+
+/**
+ * All files whose content was part of a request.
+ */
+function getSentFiles(entry: AuditEntry): string[] {
+  const files = entry.filePath ? [entry.filePath] : [];
+  for (const filePath of entry.contextFiles ?? []) {
+    if (!files.includes(filePath)) files.push(filePath);
+  }
+  return files;
+}
+
+function addToSummary(
+  byFile: Map<string, AuditFileSummary>,
+  filePath: string,
+  entry: AuditEntry,
+): void {
+  const summary: AuditFileSummary = byFile.get(filePath) ?? {
+    filePath,
+    requests: 0,
+    providers: [],
+    firstSent: entry.timestamp,
+    lastSent: entry.timestamp,
+  };
+
+  summary.requests++;
+  const provider = `${entry.provider}/${entry.model}`;
+  if (!summary.providers.includes(provider)) {
+    summary.providers.push(provider);
+  }
+  if (entry.timestamp < summary.firstSent) {
+    summary.firstSent = entry.timestamp;
+  }
+  if (entry.timestamp > summary.lastSent) {
+    summary.lastSent = entry.timestamp;
+  }
+
+  byFile.set(filePath, summary);
+}
+
+export function runAudit(args: AuditArgs): number {
+  const cwd = process.cwd();
+
+  for (const [name, value] of [
+    ["--since", args.since],
+    ["--until", args.until],
+  ] as const) {
+    if (value && Number.isNaN(Date.parse(value))) {
+      console.error(`Error: invalid date for ${name}: ${value}`);
+      return 2;
+    }
+  }
+
+  const config = loadConfig(cwd, { config: args.config });
+  const logPath = args.log ?? config.audit.path ?? getDefaultAuditLogPath();
+  const entries = filterAuditEntries(readAuditLog(logPath), args, cwd);
+
+  if (args.files) {
+    const summaries = summarizeAuditFiles(entries);
+    if (args.json) {
+      console.log(JSON.stringify(summaries, null, 2));
+    } else if (summaries.length === 0) {
+      console.log(`No matching files in ${logPath}`);
+    } else {
+      for (const summary of summaries) {
+        console.log(
+          `${summary.filePath}  ${summary.requests} request(s)  ${summary.providers.join(", ")}  last sent ${summary.lastSent}`,
+        );
+      }
+    }
+    return 0;
+  }
+
+  if (args.json) {
+    console.log(JSON.stringify(entries, null, 2));
+  } else if (entries.length === 0) {
+    console.log(`No matching entries in ${logPath}`);
+  } else {
+    for (const entry of entries) {
+      const tokens =
+        entry.promptTokens !== undefined
+          ? `${entry.promptTokens}+${entry.completionTokens ?? 0} tokens`
+          : "tokens n/a";
+      console.log(
+        `${entry.timestamp}  ${entry.provider}/${entry.model} (${entry.endpoint})  ${entry.filePath ?? "-"}  ${entry.bytes} bytes  ${tokens}  ${entry.user}@${entry.host}  ${entry.status}`,
+      );
+    }
+  }
+
+  return 0;
+}
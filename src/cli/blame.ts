@@ -0,0 +1,70 @@
+import type { Finding } from "../types/finding.js";
+import { blameRange, type BlameLine } from "../utils/git.js";
+import { logger } from "../utils/logger.js";
+
+const DAY_SECONDS = 24 * 60 * 60;
+
+/**
+ * Add the author and age of each finding's lines from git blame. The newest
+ * line in the range decides, since that change most likely introduced the
+ * issue. With rev, lines are blamed as of that commit. Findings are
+ * returned unchanged if blame fails (untracked file, no git).
+ */
+export function annotateBlame(
+  filePath: string,
+  findings: Finding[],
+  lineCount: number,
+  options: { rev?: string; now?: number } = {},
+): Finding[] {
+  const { rev, now = Date.now() } = options;
+
+  return findings.map((finding) => {
+    // Cell lines are not lines of the notebook file
+    if (!finding.range || finding.cell !== undefined) {
+      return finding;
+    }
+
+    // Ranges count lines from 0, blame from 1
+    const start = Math.max(1, Math.min(finding.range.startLine + 1, lineCount));
+    const end = Math.min(Math.max(start, finding.range.endLine + 1), lineCount);
+
+    let lines: BlameLine[];
+    try {
+      lines = blameRange(filePath, start, end, rev);
+    } catch {
+      logger.debug(`git blame failed for ${filePath}:${start}-${end}`);
+      return finding;
+    }
+
+    const newest = lines.reduce<BlameLine | null>(
+      (latest, line) => (!latest || line.time > latest.time ? line : latest),
+      null,
+    );
+    if (!newest) {
+      return finding;
+    }
+
+    return {
+      ...finding,
+      blame: {
+        author: newest.author,
+        lastModified: new Date(newest.time * 1000).toISOString(),
+        ageDays: Math.max(
+          0,
+          Math.floor((now / 1000 - newest.time) / DAY_SECONDS),
+        ),
+      },
+    };
+  });
+}
+
+/**
+ * Keep findings whose lines changed within maxAgeDays. Findings without
+ * blame information are kept.
+ */
+export function filterByAge(
+  findings: Finding[],
+  maxAgeDays: number,
+): Finding[] {
+  return findings.filter((f) => !f.blame || f.blame.ageDays <= maxAgeDays);
+}
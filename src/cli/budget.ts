@@ -0,0 +1,29 @@
+const UNIT_MS: Record<string, number> = {
+  ms: 1,
+  s: 1000,
+  m: 60 * 1000,
+  h: 60 * 60 * 1000,
+};
+
+/**
+ * Parse a duration like "10m", "90s" or "1h30m" into milliseconds. A plain
+ * number is seconds.
+ */
+export function parseDuration(value: string): number {
+  const text = value.trim();
+  if (/^\d+(\.\d+)?$/.test(text)) {
+    return Number(text) * 1000;
+  }
+
+  const parts = [...text.matchAll(/(\d+(?:\.\d+)?)(ms|s|m|h)/g)];
+  if (parts.length === 0 || parts.map((p) => p[0]).join("") !== text) {
+    throw new Error(
+      `Invalid duration "${value}", expected e.g. 90s, 10m or 1h30m`,
+    );
+  }
+
+  return parts.reduce(
+    (ms, [, amount, unit]) => ms + Number(amount) * UNIT_MS[unit],
+    0,
+  );
+}
@@ -0,0 +1,83 @@
+import { resolve } from "node:path";
+import { loadConfig } from "../config/loader.js";
+import { createRedactor } from "../core/redactor.js";
+import {
+  buildEmbeddingIndex,
+  loadEmbeddingIndex,
+  saveEmbeddingIndex,
+} from "../core/embedding-index.js";
+import { getSupportedExtensions } from "../core/languages.js";
+import { logger } from "../utils/logger.js";
+import { resolveFiles } from "./index.js";
+
+export interface IndexArgs {
+  paths: string[];
+  config?: string;
+  debug: boolean;
+  rebuild: boolean;
+}
+
+/**
+ * Build or update the embedding index used for retrieval.
+ */
+export async function runIndex(args: IndexArgs): Promise<number> {
+  const cwd = process.cwd();
+  const config = loadConfig(cwd, { config: args.config, debug: args.debug });
+
+  if (config.debug) {
+    logger.setLevel("debug");
+  }
+
+  const retrieval = config.analysis.retrieval;
+  const indexPath = resolve(cwd, retrieval.indexPath);
+  const extensions = getSupportedExtensions().map((e) => e.slice(1));
+  const files = await resolveFiles(
+    args.paths,
+    extensions,
+    Number.POSITIVE_INFINITY,
+  );
+
+  if (files.length === 0) {
+    console.error("No files found to index");
+    return 2;
+  }
+
+  const previous = args.rebuild ? null : loadEmbeddingIndex(indexPath);
+  console.log(
+    `Indexing ${files.length} file(s) with ${retrieval.model} at ${retrieval.baseUrl}...`,
+  );
+
+  try {
+    const result = await buildEmbeddingIndex({
+      files,
+      rootDir: cwd,
+      config,
+      redactor: createRedactor(config.privacy),
+      previous,
+      onFile: (filePath, chunks) =>
+        logger.debug(`Indexed ${filePath} (${chunks} chunk(s))`),
+    });
+
+    saveEmbeddingIndex(indexPath, result.index);
+
+    console.log(
+      `Wrote ${result.index.chunks.length} chunk(s) to ${retrieval.indexPath} (${result.embedded} embedded, ${result.reused} unchanged)`,
+    );
+    if (result.skipped.length > 0) {
+      console.log(
+        `Skipped ${result.skipped.length} file(s) excluded by the privacy policy`,
+      );
+    }
+    if (!retrieval.enabled) {
+      console.log(
+        'Set "analysis.retrieval.enabled": true in lintai.json to use the index',
+      );
+    }
+    return 0;
+  } catch (error) {
+    console.error(
+      `Error: indexing failed: ${error instanceof Error ? error.message : error}`,
+    );
+    return 2;
+  }
+}
@@ -0,0 +1,121 @@
+/**
+ * Progress of a CLI run, so an interrupted run can continue with --resume.
+ * Each finished file is appended as one JSON line, so the checkpoint
+ * survives the process being killed; a torn last line is ignored.
+ */
+
+import {
+  appendFileSync,
+  existsSync,
+  mkdirSync,
+  readFileSync,
+  rmSync,
+  writeFileSync,
+} from "node:fs";
+import { dirname, join } from "node:path";
+import type { AnalysisResult } from "../core/analyzer.js";
+import type { Redactor } from "../core/redactor.js";
+import { logger } from "../utils/logger.js";
+import type { Shard } from "./shard.js";
+
+const CHECKPOINT_VERSION = 1;
+
+export interface CheckpointEntry {
+  path: string;
+  contentHash: string; // Reused only if the file has not changed
+  result: AnalysisResult;
+}
+
+/**
+ * Checkpoint file for a run. Shards get their own, so parallel jobs in one
+ * checkout do not overwrite each other.
+ */
+export function checkpointPath(rootDir: string, shard?: Shard): string {
+  const name = shard
+    ? `checkpoint-${shard.index}of${shard.count}.jsonl`
+    : "checkpoint.jsonl";
+  return join(rootDir, ".lintai", name);
+}
+
+/**
+ * Completed files from a checkpoint, keyed by path. Returns an empty map if
+ * there is no checkpoint or it was written with a different configuration.
+ */
+export function loadCheckpoint(
+  path: string,
+  configHash: string,
+): Map<string, CheckpointEntry> {
+  const entries = new Map<string, CheckpointEntry>();
+  if (!existsSync(path)) {
+    return entries;
+  }
+
+  const [header, ...lines] = readFileSync(path, "utf-8").split("\n");
+  try {
+    const parsed = JSON.parse(header);
+    if (
+      parsed.version !== CHECKPOINT_VERSION ||
+      parsed.configHash !== configHash
+    ) {
+      logger.warn("Checkpoint was written with a different config, ignoring");
+      return entries;
+    }
+  } catch {
+    logger.warn(`Cannot read checkpoint ${path}, ignoring`);
+    return entries;
+  }
+
+  for (const line of lines) {
+    if (!line.trim()) continue;
+    try {
+      const entry = JSON.parse(line) as CheckpointEntry;
+      entries.set(entry.path, entry);
+    } catch {
+      // Last line cut off by an interrupted write
+      logger.debug(`Skipping unreadable checkpoint line in ${path}`);
+    }
+  }
+
+  return entries;
+}
+
+/**
+ * Start a checkpoint, keeping the entries reused from a previous run.
+ */
+export function startCheckpoint(
+  path: string,
+  configHash: string,
+  kept: CheckpointEntry[] = [],
+): void {
+  mkdirSync(dirname(path), { recursive: true });
+  const lines = [
+    JSON.stringify({ version: CHECKPOINT_VERSION, configHash }),
+    ...kept.map((entry) => JSON.stringify(entry)),
+  ];
+  writeFileSync(path, lines.join("\n") + "\n");
+}
+
+/**
+ * Append a finished file. Findings carry the original secret values once
+ * placeholders are restored, so with a redactor they are scrubbed again
+ * before they reach the disk.
+ */
+export function recordCheckpoint(
+  path: string,
+  entry: CheckpointEntry,
+  redactor: Redactor | null = null,
+): void {
+  const scrub = (text: string) => redactor?.redact(text).text ?? text;
+  const findings = entry.result.findings.map((finding) => ({
+    ...finding,
+    title: scrub(finding.title),
+    message: scrub(finding.message),
+    suggestion: scrub(finding.suggestion),
+  }));
+  const scrubbed = { ...entry, result: { ...entry.result, findings } };
+  appendFileSync(path, JSON.stringify(scrubbed) + "\n");
+}
+
+export function removeCheckpoint(path: string): void {
+  rmSync(path, { force: true });
+}
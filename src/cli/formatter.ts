@@ -1,6 +1,13 @@
+import { relative } from "node:path";
 import type { Finding } from "../types/finding.js";
 import type { AnalysisResult } from "../core/analyzer.js";
 import { categoryToString } from "../core/diagnostics-mapper.js";
+import type { TriageGroup } from "../core/triage.js";
+import { diagnosticPath } from "../core/external-results.js";
+import type { Shard } from "./shard.js";
+
+// Group for files no CODEOWNERS rule assigns
+export const UNOWNED = "(unowned)";
 
 const COLORS = {
   reset: "\x1b[0m",
@@ -48,9 +55,10 @@ export function formatFinding(
   const icon = SEVERITY_ICONS[finding.severity] || "•";
 
   // Location
+  const cell = finding.cell !== undefined ? ` cell ${finding.cell}` : "";
   const location = finding.range
-    ? `${filePath}:${finding.range.startLine + 1}:${finding.range.startCharacter + 1}`
-    : filePath;
+    ? `${filePath}${cell}:${finding.range.startLine + 1}:${finding.range.startCharacter + 1}`
+    : `${filePath}${cell}`;
 
   // Header: location + severity + code
   const header = `${colorize(location, COLORS.bold, useColor)} ${colorize(`${icon} ${finding.severity}`, sevColor, useColor)} ${colorize(`[${finding.id}]`, COLORS.dim, useColor)}`;
@@ -70,7 +78,10 @@ export function formatFinding(
   }
 
   // Category and confidence
-  const meta = `${categoryToString(finding.category)} • confidence: ${Math.round(finding.confidence * 100)}%`;
+  let meta = `${categoryToString(finding.category)} • confidence: ${Math.round(finding.confidence * 100)}%`;
+  if (finding.blame) {
+    meta += ` • changed ${finding.blame.ageDays}d ago by ${finding.blame.author}`;
+  }
   lines.push(`  ${colorize(meta, COLORS.dim, useColor)}`);
 
   return lines.join("\n");
@@ -92,7 +103,11 @@ export function formatResults(
     lines.push(colorize(`  ⚠ ${result.error}`, COLORS.yellow, useColor));
   }
 
-  if (result.findings.length === 0) {
+  if (result.skipped) {
+    lines.push(
+      colorize(`  – Skipped: ${result.skipped}`, COLORS.dim, useColor),
+    );
+  } else if (result.findings.length === 0) {
     lines.push(colorize("  ✓ No issues found", COLORS.cyan, useColor));
   } else {
     for (const finding of result.findings) {
@@ -153,43 +168,352 @@ export function formatSummary(
   return lines.join("\n");
 }
 
+// Why a run stopped before analyzing every file
+export type StopReason = "interrupted" | "time-budget" | "request-budget";
+
+export interface StoppedRun {
+  reason: StopReason;
+  skipped: string[]; // Files not analyzed
+}
+
+const STOP_REASONS: Record<StopReason, string> = {
+  interrupted: "the run was interrupted",
+  "time-budget": "the time budget ran out",
+  "request-budget": "the request budget ran out",
+};
+
+/**
+ * Why a run stopped early and the files it did not analyze.
+ */
+export function formatStopped(
+  stopped: StoppedRun,
+  options: FormatOptions = {},
+): string {
+  const { useColor = true } = options;
+  const maxListed = 20;
+  const { skipped } = stopped;
+  const lines: string[] = [];
+
+  lines.push("");
+  lines.push(colorize("━━━ Incomplete ━━━", COLORS.bold, useColor));
+  lines.push(
+    `  Stopped because ${STOP_REASONS[stopped.reason]}; ${skipped.length} files were not analyzed`,
+  );
+  for (const path of skipped.slice(0, maxListed)) {
+    lines.push(colorize(`  ${path}`, COLORS.dim, useColor));
+  }
+  if (skipped.length > maxListed) {
+    lines.push(`  ... and ${skipped.length - maxListed} more`);
+  }
+
+  return lines.join("\n");
+}
+
 export interface JSONOutput {
   files: Array<{
     path: string;
+    owners?: string[]; // From CODEOWNERS, if the repository has one
     findings: Finding[];
     error?: string;
+    skipped?: string; // Why the file was not analyzed
   }>;
   summary: {
     totalFiles: number;
     totalFindings: number;
     bySeverity: Record<string, number>;
   };
+  shard?: Shard; // Set for --shard runs, checked by lintai merge
+  incomplete?: boolean; // Stopped before analyzing every file
+  stopReason?: StopReason;
+  skipped?: string[];
 }
 
-export function formatJSON(results: Map<string, AnalysisResult>): string {
-  const output: JSONOutput = {
-    files: [],
-    summary: {
-      totalFiles: results.size,
-      totalFindings: 0,
-      bySeverity: {},
-    },
-  };
+export interface JSONFormatOptions {
+  owners?: Map<string, string[]>;
+  shard?: Shard;
+  stopped?: StoppedRun;
+  // Write paths relative to this directory (the repository root for
+  // --shard), so reports from different checkouts can be merged
+  rootDir?: string;
+}
+
+export function formatJSON(
+  results: Map<string, AnalysisResult>,
+  options: JSONFormatOptions = {},
+): string {
+  const { rootDir } = options;
+  const pathFor = (path: string) =>
+    rootDir ? relative(rootDir, path).replace(/\\/g, "/") : path;
+  const files: JSONOutput["files"] = [];
 
   for (const [path, result] of results) {
-    output.files.push({
-      path,
+    files.push({
+      path: pathFor(path),
+      owners: options.owners?.get(path),
       findings: result.findings,
       error: result.error,
+      skipped: result.skipped,
     });
+  }
+
+  const output: JSONOutput = {
+    files,
+    summary: summarizeJSONFiles(files),
+    shard: options.shard,
+    incomplete: options.stopped ? true : undefined,
+    stopReason: options.stopped?.reason,
+    skipped: options.stopped?.skipped.map(pathFor),
+  };
+
+  return JSON.stringify(output, null, 2);
+}
+
+/**
+ * Totals for the files of a JSON report.
+ */
+export function summarizeJSONFiles(
+  files: JSONOutput["files"],
+): JSONOutput["summary"] {
+  const summary: JSONOutput["summary"] = {
+    totalFiles: files.length,
+    totalFindings: 0,
+    bySeverity: {},
+  };
+
+  for (const file of files) {
+    summary.totalFindings += file.findings.length;
+
+    for (const finding of file.findings) {
+      summary.bySeverity[finding.severity] =
+        (summary.bySeverity[finding.severity] || 0) + 1;
+    }
+  }
+
+  return summary;
+}
+
+/**
+ * Split results by owner. A file with several owners appears under each;
+ * files without an owner are grouped under UNOWNED, which sorts last.
+ */
+export function groupByOwner(
+  results: Map<string, AnalysisResult>,
+  owners: Map<string, string[]>,
+): Map<string, Map<string, AnalysisResult>> {
+  const groups = new Map<string, Map<string, AnalysisResult>>();
+
+  for (const [path, result] of results) {
+    const fileOwners = owners.get(path) ?? [];
+    for (const owner of fileOwners.length > 0 ? fileOwners : [UNOWNED]) {
+      const group = groups.get(owner) ?? new Map<string, AnalysisResult>();
+      groups.set(owner, group.set(path, result));
+    }
+  }
+
+  return new Map(
+    [...groups].sort(([a], [b]) =>
+      a === UNOWNED ? 1 : b === UNOWNED ? -1 : a.localeCompare(b),
+    ),
+  );
+}
+
+/**
+ * Results grouped by owner, for handing each team its findings. Files
+ * without findings or errors are left out.
+ */
+export function formatOwnerGroups(
+  groups: Map<string, Map<string, AnalysisResult>>,
+  options: FormatOptions = {},
+): string {
+  const { useColor = true } = options;
+  const lines: string[] = [];
+
+  for (const [owner, results] of groups) {
+    const reported = [...results].filter(
+      ([, result]) => result.findings.length > 0 || result.error,
+    );
+    if (reported.length === 0) continue;
+
+    const issues = reported.reduce((n, [, r]) => n + r.findings.length, 0);
+    lines.push("");
+    lines.push(
+      colorize(
+        `═══ ${owner} (${issues} issues in ${reported.length} files) ═══`,
+        COLORS.bold,
+        useColor,
+      ),
+    );
+    for (const [path, result] of reported) {
+      lines.push(formatResults(path, result, options));
+    }
+  }
+
+  return lines.join("\n");
+}
+
+export interface MarkdownOptions {
+  rootDir: string;
+  groups?: Map<string, Map<string, AnalysisResult>>; // From groupByOwner
+  stopped?: StoppedRun;
+}
+
+/**
+ * Markdown report for pull request comments and issues. Only files with
+ * findings or errors are listed.
+ */
+export function formatMarkdown(
+  results: Map<string, AnalysisResult>,
+  options: MarkdownOptions,
+): string {
+  const { rootDir, groups, stopped } = options;
+  const lines: string[] = ["# lintai report", ""];
 
-    output.summary.totalFindings += result.findings.length;
+  if (stopped) {
+    lines.push(
+      `> **Incomplete:** ${STOP_REASONS[stopped.reason]}; ${stopped.skipped.length} files were not analyzed.`,
+      "",
+    );
+  }
 
+  const findings = [...results.values()].flatMap((r) => r.findings);
+  const counts = (["error", "warning", "info", "hint"] as const)
+    .map((severity) => {
+      const n = findings.filter((f) => f.severity === severity).length;
+      const plural = n === 1 || severity === "info" ? "" : "s";
+      return n > 0 ? `${n} ${severity}${plural}` : "";
+    })
+    .filter(Boolean);
+  lines.push(
+    `${results.size} files analyzed, ${findings.length} issues` +
+      (counts.length > 0 ? ` (${counts.join(", ")})` : ""),
+  );
+
+  const fileSection = (
+    path: string,
+    result: AnalysisResult,
+    level: string,
+  ) => {
+    const file = relative(rootDir, path).replace(/\\/g, "/");
+    lines.push("", `${level} \`${file}\``, "");
+    if (result.error) {
+      lines.push(`> ${result.error}`, "");
+    }
     for (const finding of result.findings) {
-      output.summary.bySeverity[finding.severity] =
-        (output.summary.bySeverity[finding.severity] || 0) + 1;
+      const cell = finding.cell !== undefined ? ` cell ${finding.cell}` : "";
+      const line = finding.range ? `:${finding.range.startLine + 1}` : "";
+      lines.push(
+        `- **${finding.severity}** \`${file}${cell}${line}\` ${finding.title} (${categoryToString(finding.category)})`,
+      );
+      lines.push(`  ${finding.message}`);
+      if (finding.suggestion) {
+        lines.push(`  Suggestion: ${finding.suggestion}`);
+      }
+    }
+  };
+
+  const isReported = ([, result]: [string, AnalysisResult]) =>
+    result.findings.length > 0 || result.error !== undefined;
+
+  if (groups) {
+    for (const [owner, ownerResults] of groups) {
+      const reported = [...ownerResults].filter(isReported);
+      if (reported.length === 0) continue;
+      lines.push("", `## ${owner}`);
+      for (const [path, result] of reported) {
+        fileSection(path, result, "###");
+      }
+    }
+  } else {
+    for (const [path, result] of [...results].filter(isReported)) {
+      fileSection(path, result, "##");
     }
   }
 
-  return JSON.stringify(output, null, 2);
+  if (stopped && stopped.skipped.length > 0) {
+    lines.push("", "## Not analyzed", "");
+    for (const path of stopped.skipped) {
+      lines.push(`- \`${relative(rootDir, path).replace(/\\/g, "/")}\``);
+    }
+  }
+
+  return lines.join("\n") + "\n";
+}
+
+const PRIORITY_COLORS: Record<string, string> = {
+  high: COLORS.red,
+  medium: COLORS.yellow,
+  low: COLORS.gray,
+};
+
+export interface TriageFormatOptions extends FormatOptions {
+  rootDir: string;
+  top?: number; // Groups shown with explanation and samples
+}
+
+export function formatTriageReport(
+  groups: TriageGroup[],
+  options: TriageFormatOptions,
+): string {
+  const { useColor = true, rootDir, top = 10 } = options;
+  const lines: string[] = [];
+
+  for (const [index, group] of groups.entries()) {
+    const name = `${group.source}${group.rule ? ` ${group.rule}` : ""}`;
+    const priority = group.priority ?? "untriaged";
+    const label = colorize(
+      priority.padEnd(9),
+      PRIORITY_COLORS[priority] ?? COLORS.dim,
+      useColor,
+    );
+    const noise = group.likelyFalsePositive
+      ? colorize(" (likely false positive)", COLORS.dim, useColor)
+      : "";
+    lines.push(
+      `${label} ${colorize(name, COLORS.bold, useColor)} × ${group.diagnostics.length}${noise}`,
+    );
+
+    if (index >= top) {
+      continue;
+    }
+
+    if (group.explanation) {
+      lines.push(`  ${group.explanation}`);
+    }
+    for (const diagnostic of group.samples) {
+      const location = `${diagnosticPath(diagnostic, rootDir)}:${diagnostic.line}`;
+      const marker = group.falsePositives.has(diagnostic)
+        ? colorize(" [false positive]", COLORS.dim, useColor)
+        : "";
+      lines.push(
+        `  ${colorize(location, COLORS.dim, useColor)} ${diagnostic.message}${marker}`,
+      );
+    }
+    lines.push("");
+  }
+
+  return lines.join("\n");
+}
+
+export function formatTriageJSON(
+  groups: TriageGroup[],
+  rootDir: string,
+): string {
+  return JSON.stringify(
+    groups.map((group) => ({
+      id: group.id,
+      source: group.source,
+      rule: group.rule,
+      count: group.diagnostics.length,
+      priority: group.priority,
+      explanation: group.explanation,
+      likelyFalsePositive: group.likelyFalsePositive ?? false,
+      falsePositives: Array.from(group.falsePositives, (d) => ({
+        path: diagnosticPath(d, rootDir),
+        line: d.line,
+        message: d.message,
+      })),
+    })),
+    null,
+    2,
+  );
 }
@@ -1,18 +1,70 @@
 import { readFileSync, statSync, existsSync } from "node:fs";
 import { resolve, extname } from "node:path";
 import { glob } from "glob";
-import { loadConfig, validateAPIKey } from "../config/loader.js";
-import { analyze, type AnalysisResult } from "../core/analyzer.js";
+import {
+  getConfigHash,
+  loadConfig,
+  validateAPIKey,
+} from "../config/loader.js";
+import {
+  analyze,
+  analyzeUnit,
+  type AnalysisResult,
+} from "../core/analyzer.js";
+import { groupIntoUnits } from "../core/units.js";
+import { loadCoverageReports, orderByUncovered } from "../core/coverage.js";
+import {
+  groupDiagnosticsByFile,
+  loadExternalResults,
+  type ExternalDiagnostic,
+} from "../core/external-results.js";
+import { isOwnedBy, loadCodeowners, ownersOf } from "../core/codeowners.js";
+import { splitLines } from "../core/positions.js";
+import { detectSkipReason } from "../core/skip-detect.js";
+import { createRedactor } from "../core/redactor.js";
+import { getGlobalRequestQueue } from "../llm/request-queue.js";
+import {
+  getLLMRequestCount,
+  isLLMRequestBudgetExceeded,
+  setLLMRequestBudget,
+} from "../llm/client.js";
 import { logger } from "../utils/logger.js";
-import { formatResults, formatSummary, formatJSON } from "./formatter.js";
+import { computeHash } from "../utils/hash.js";
+import { findMatchingGlob } from "../utils/glob-match.js";
+import { getRepoRoot } from "../utils/git.js";
+import {
+  formatResults,
+  formatSummary,
+  formatJSON,
+  formatMarkdown,
+  formatOwnerGroups,
+  formatStopped,
+  groupByOwner,
+  type StopReason,
+  type StoppedRun,
+} from "./formatter.js";
+import { formatSARIF } from "./sarif.js";
+import { orderFiles } from "./ordering.js";
+import { selectShard, type Shard } from "./shard.js";
+import { loadRevisionFiles, type RevisionSource } from "./revisions.js";
+import {
+  checkpointPath,
+  loadCheckpoint,
+  recordCheckpoint,
+  removeCheckpoint,
+  startCheckpoint,
+  type CheckpointEntry,
+} from "./checkpoint.js";
+import { annotateBlame, filterByAge } from "./blame.js";
 import { initConfig } from "./init.js";
-import type { LLMProvider } from "../types/config.js";
+import type { FileOrder, LLMProvider, OutputFormat } from "../types/config.js";
 
 export interface CLIArgs {
   paths: string[];
   lsp: boolean;
   init: boolean;
-  json: boolean;
+  json: boolean; // Shorthand for format "json"
+  format?: OutputFormat;
   debug: boolean;
   config?: string;
   ext?: string;
@@ -20,10 +72,32 @@ export interface CLIArgs {
   model?: string;
   baseUrl?: string;
   provider?: LLMProvider;
+  withResults?: string[]; // Results files from other linters
+  coverage?: string[]; // Coverage reports
+  order?: FileOrder;
+  seed?: number;
+  blame?: boolean;
+  maxAge?: number; // Days; only findings on recently changed lines
+  groupBy?: "owner";
+  owner?: string[]; // Only files owned by these CODEOWNERS owners
+  shard?: Shard;
+  resume?: boolean; // Reuse results from an interrupted run's checkpoint
+  timeBudget?: number; // Milliseconds for the whole run
+  maxRequests?: number; // LLM requests for the whole run
+  include?: string[]; // Globs for files to analyze regardless of extension
+  rev?: string; // Analyze files as of this commit
+  range?: string; // Analyze file versions changed in A..B
 }
 
+// Exit code when the run is stopped by SIGINT or SIGTERM
+const EXIT_INTERRUPTED = 130;
+
+// Exit code when --time-budget or --max-requests runs out
+const EXIT_BUDGET = 3;
+
 export async function runCLI(args: CLIArgs): Promise<number> {
   const cwd = process.cwd();
+  const startTime = Date.now();
 
   // Handle --init
   if (args.init) {
@@ -36,10 +110,19 @@ export async function runCLI(args: CLIArgs): Promise<number> {
   const config = loadConfig(cwd, {
     config: args.config,
     debug: args.debug,
-    format: args.json ? "json" : "human",
+    format: args.format ?? (args.json ? "json" : undefined),
     model: args.model,
     baseUrl: args.baseUrl,
     provider: args.provider,
+    coverage: args.coverage,
+    order: args.order,
+    seed: args.seed,
+    blame: args.blame,
+    extensions: args.ext
+      ?.split(",")
+      .map((e) => e.trim())
+      .filter(Boolean),
+    include: args.include,
   });
 
   // Set up logging
@@ -56,56 +139,398 @@ export async function runCLI(args: CLIArgs): Promise<number> {
     return 2;
   }
 
+  const format = config.cli.format;
+
+  // Owners from CODEOWNERS, for grouping and filtering. Its patterns are
+  // relative to the repository root, wherever lintai runs from.
+  const repoRoot = getRepoRoot(cwd) ?? cwd;
+  const codeowners = loadCodeowners(repoRoot);
+  const ownerFilter = args.owner?.length ? args.owner : undefined;
+  if (!codeowners && (args.groupBy === "owner" || ownerFilter)) {
+    console.error(
+      "Error: --group-by owner and --owner need a CODEOWNERS file (CODEOWNERS, .github/, docs/ or .gitlab/)",
+    );
+    return 2;
+  }
+
+  // With --rev or --range, files come from git objects, not the work tree
+  let revision: RevisionSource | undefined;
+  if (args.rev || args.range) {
+    try {
+      revision = loadRevisionFiles({
+        rev: args.rev,
+        range: args.range,
+        paths: args.paths,
+        extensions: config.cli.extensions,
+        include: config.cli.include,
+        cwd,
+      });
+    } catch (error) {
+      console.error(
+        `Error: Cannot read ${args.range ?? args.rev} from git: ${error instanceof Error ? error.message : error}`,
+      );
+      return 2;
+    }
+  }
+  const readSource = (filePath: string) =>
+    revision ? revision.read(filePath) : readFileSync(filePath, "utf-8");
+  const sizeOf = (filePath: string) =>
+    revision ? readSource(filePath).length : statSync(filePath).size;
+  // Work tree path of a result, e.g. without the @commit of a range version
+  const pathOf = (filePath: string) =>
+    revision?.files.get(filePath)?.path ?? filePath;
+
   // Get files to analyze
-  const files = await resolveFiles(
-    args.paths,
-    config.cli.extensions,
-    config.cli.maxFiles,
-  );
+  // Decide which files come first when maxFiles truncates the list
+  const { order, seed } = config.cli;
+  const coverage = config.analysis.coverage;
+  const select = (found: string[]) => {
+    // Shard first, so maxFiles applies to each shard
+    // Keyed on repository paths, so every runner agrees on the shards
+    const sharded = args.shard
+      ? selectShard(found, args.shard, repoRoot)
+      : found;
+    const owned = ownerFilter
+      ? sharded.filter((f) =>
+          isOwnedBy(ownersOf(codeowners!, pathOf(f), repoRoot), ownerFilter),
+        )
+      : sharded;
+    // Orders describe the work tree, so revisions keep git's order
+    if (revision) {
+      return owned;
+    }
+    const ordered = orderFiles(owned, order, { cwd, seed });
+    // Stable sort, so the order above breaks ties
+    return coverage.prioritize
+      ? orderByCoverage(ordered, coverage.reports, cwd)
+      : ordered;
+  };
+  const maxFiles = args.maxFiles ?? config.cli.maxFiles;
+  const files = revision
+    ? selectRevisionFiles(Array.from(revision.files.keys()), maxFiles, select)
+    : await resolveFiles(
+        args.paths,
+        config.cli.extensions,
+        maxFiles,
+        order !== "default" || coverage.prioritize || ownerFilter || args.shard
+          ? select
+          : undefined,
+        config.cli.include,
+      );
 
-  if (files.length === 0) {
+  // A shard can be empty in a small repository; it still writes a report
+  if (files.length === 0 && !args.shard) {
     console.error("No files found to analyze");
     return 2;
   }
 
-  logger.info(`Analyzing ${files.length} file(s)...`);
+  const owners = codeowners
+    ? new Map(
+        files.map((f) => [f, ownersOf(codeowners, pathOf(f), repoRoot)]),
+      )
+    : undefined;
+
+  // Diagnostics other linters already report, keyed by file
+  let knownIssues = new Map<string, ExternalDiagnostic[]>();
+  if (args.withResults && args.withResults.length > 0) {
+    const diagnostics: ExternalDiagnostic[] = [];
+    for (const resultsPath of args.withResults) {
+      try {
+        diagnostics.push(...loadExternalResults(resultsPath, cwd));
+      } catch (error) {
+        console.error(
+          `Error: Cannot read results from ${resultsPath}: ${error instanceof Error ? error.message : error}`,
+        );
+        return 2;
+      }
+    }
+    knownIssues = groupDiagnosticsByFile(diagnostics);
+    logger.debug(
+      `Loaded ${diagnostics.length} diagnostic(s) from ${args.withResults.length} results file(s)`,
+    );
+  }
+
+  // Checkpoint progress so an interrupted run can be resumed. A checkout
+  // that cannot be written to is analyzed without one.
+  const configHash = getConfigHash(config);
+  const checkpointFile = checkpointPath(cwd, args.shard);
+  const checkpointRedactor = createRedactor(config.privacy);
+  // Cleared by dropCheckpoint; the cast keeps TypeScript from narrowing it
+  let checkpoint = checkpointFile as string | undefined;
+  const dropCheckpoint = (error: unknown) => {
+    logger.warn(
+      `Cannot write checkpoint ${checkpointFile}, continuing without one: ${error instanceof Error ? error.message : error}`,
+    );
+    checkpoint = undefined;
+  };
+  const completed = args.resume
+    ? loadCheckpoint(checkpointFile, configHash)
+    : new Map<string, CheckpointEntry>();
+  const resumed: CheckpointEntry[] = [];
+  const hashFile = (filePath: string) => computeHash(readSource(filePath));
+  const pending = files.filter((filePath) => {
+    const entry = completed.get(filePath);
+    if (entry?.contentHash === hashFile(filePath)) {
+      resumed.push(entry);
+      return false;
+    }
+    return true;
+  });
+  try {
+    startCheckpoint(checkpointFile, configHash, resumed);
+  } catch (error) {
+    dropCheckpoint(error);
+  }
+
+  if (resumed.length > 0) {
+    logger.info(`Resuming: ${resumed.length} file(s) already analyzed`);
+  }
+  logger.info(`Analyzing ${pending.length} file(s)...`);
+
+  // Stop on SIGINT/SIGTERM or when a budget runs out: cancel requests and
+  // report what is done. A second signal exits immediately.
+  // Set by the handlers below; the cast keeps TypeScript from narrowing it
+  let stopped = undefined as StopReason | undefined;
+  const onSignal = (signal: NodeJS.Signals) => {
+    if (stopped === "interrupted") {
+      process.exit(EXIT_INTERRUPTED);
+    }
+    stopped = "interrupted";
+    logger.warn(`${signal} received, writing partial report`);
+    getGlobalRequestQueue().cancelAll();
+  };
+  process.on("SIGINT", onSignal);
+  process.on("SIGTERM", onSignal);
+
+  // The deadline also cancels the request in flight. The client refuses
+  // requests past the request budget, also those made within a file.
+  const deadline =
+    args.timeBudget !== undefined
+      ? setTimeout(
+          () => {
+            if (stopped) return;
+            stopped = "time-budget";
+            logger.warn("Time budget used up, writing partial report");
+            getGlobalRequestQueue().cancelAll();
+          },
+          Math.max(0, args.timeBudget - (Date.now() - startTime)),
+        )
+      : undefined;
+  const requestsAtStart = getLLMRequestCount();
+  setLLMRequestBudget(args.maxRequests);
+  const stopOnBudget = () => {
+    if (stopped) return;
+    stopped = "request-budget";
+    logger.warn(
+      `Request budget of ${args.maxRequests} used up, writing partial report`,
+    );
+  };
+  // Checked before each file or unit
+  const shouldStop = () => {
+    if (
+      args.maxRequests !== undefined &&
+      getLLMRequestCount() - requestsAtStart >= args.maxRequests
+    ) {
+      stopOnBudget();
+    }
+    return stopped !== undefined;
+  };
+  // Checked after each: results are partial if a request was refused
+  const wasStopped = () => {
+    if (isLLMRequestBudgetExceeded()) {
+      stopOnBudget();
+    }
+    return stopped !== undefined;
+  };
 
   // Analyze files
   const results = new Map<string, AnalysisResult>();
 
-  for (const filePath of files) {
-    try {
-      const content = readFileSync(filePath, "utf-8");
-      const result = await analyze({
-        filePath,
-        content,
-        config,
-      });
-      results.set(filePath, result);
-
-      // Print progress for non-JSON output
-      if (!args.json) {
-        console.log(
-          formatResults(filePath, result, {
-            useColor: true,
-            showMetrics: config.debug,
-          }),
+  const withBlame = config.cli.blame || args.maxAge !== undefined;
+
+  const report = (filePath: string, result: AnalysisResult, save = true) => {
+    // Failed files are not saved, so --resume retries them
+    if (checkpoint && save && !result.error) {
+      try {
+        recordCheckpoint(
+          checkpoint,
+          { path: filePath, contentHash: hashFile(filePath), result },
+          checkpointRedactor,
         );
+      } catch (error) {
+        dropCheckpoint(error);
       }
-    } catch (error) {
-      logger.error(`Error analyzing ${filePath}:`, error);
-      results.set(filePath, {
-        findings: [],
-        error: error instanceof Error ? error.message : "Unknown error",
-        cached: false,
-      });
     }
+
+    if (withBlame && result.findings.length > 0) {
+      const lineCount = splitLines(readSource(filePath)).length;
+      let findings = annotateBlame(
+        pathOf(filePath),
+        result.findings,
+        lineCount,
+        { rev: revision?.files.get(filePath)?.commit },
+      );
+      if (args.maxAge !== undefined) {
+        findings = filterByAge(findings, args.maxAge);
+      }
+      result = { ...result, findings };
+    }
+
+    results.set(filePath, result);
+
+    // Print progress for human output; grouped output is printed at the end
+    if (format === "human" && args.groupBy !== "owner") {
+      console.log(
+        formatResults(filePath, result, {
+          useColor: true,
+          showMetrics: config.debug,
+        }),
+      );
+    }
+  };
+
+  const reportError = (filePath: string, error: unknown) => {
+    logger.error(`Error analyzing ${filePath}:`, error);
+    report(filePath, {
+      findings: [],
+      error: error instanceof Error ? error.message : "Unknown error",
+      cached: false,
+    });
+  };
+
+  for (const entry of resumed) {
+    report(entry.path, entry.result, false);
+  }
+
+  // Generated, vendored and minified files are reported without a request
+  const analyzable = pending.filter((filePath) => {
+    const reason = config.analysis.skipGenerated
+      ? detectSkipReason(pathOf(filePath), readSource(filePath), cwd)
+      : null;
+    if (reason) {
+      logger.debug(`Skipping ${filePath}: ${reason}`);
+      report(filePath, { findings: [], skipped: reason, cached: false });
+    }
+    return !reason;
+  });
+  if (analyzable.length < pending.length) {
+    logger.info(
+      `Skipped ${pending.length - analyzable.length} generated, vendored or minified file(s)`,
+    );
+  }
+
+  if (config.analysis.unit === "package") {
+    // Send the files of each package/directory together
+    const units = groupIntoUnits(
+      analyzable,
+      config.analysis.maxUnitTokens,
+      (filePath) => Math.ceil(sizeOf(filePath) / 4),
+      (filePath) => ({
+        path: pathOf(filePath),
+        commit: revision?.files.get(filePath)?.commit,
+      }),
+    );
+
+    for (const unit of units) {
+      if (shouldStop()) break;
+      try {
+        // Units are keyed on commit, directory and language, so the
+        // versions in one unit are from one commit and their paths distinct
+        const unitResults = await analyzeUnit({
+          files: unit.map((filePath) => ({
+            filePath: pathOf(filePath),
+            content: readSource(filePath),
+            knownIssues: knownIssues.get(pathOf(filePath)),
+          })),
+          config,
+          rootDir: cwd,
+        });
+        // Results of a cancelled or refused request are not real results
+        if (wasStopped()) break;
+        for (const filePath of unit) {
+          const result = unitResults.get(pathOf(filePath));
+          if (result) report(filePath, result);
+        }
+      } catch (error) {
+        if (wasStopped()) break;
+        for (const filePath of unit) {
+          reportError(filePath, error);
+        }
+      }
+    }
+  } else {
+    for (const filePath of analyzable) {
+      if (shouldStop()) break;
+      try {
+        const content = readSource(filePath);
+        // Analyze under the work tree path, so the extension is detected
+        const result = await analyze({
+          filePath: pathOf(filePath),
+          content,
+          config,
+          rootDir: cwd,
+          knownIssues: knownIssues.get(pathOf(filePath)),
+        });
+        if (wasStopped()) break;
+        report(filePath, result);
+      } catch (error) {
+        if (wasStopped()) break;
+        reportError(filePath, error);
+      }
+    }
+  }
+
+  process.off("SIGINT", onSignal);
+  process.off("SIGTERM", onSignal);
+  clearTimeout(deadline);
+  setLLMRequestBudget(undefined);
+
+  // Keep the checkpoint of a run that stopped early for --resume
+  const stoppedRun: StoppedRun | undefined = stopped && {
+    reason: stopped,
+    skipped: files.filter((filePath) => !results.has(filePath)),
+  };
+  if (stoppedRun) {
+    logger.warn(
+      `Stopped after ${results.size} of ${files.length} file(s)${checkpoint ? "; run again with --resume to continue" : ""}`,
+    );
+  } else if (checkpoint) {
+    removeCheckpoint(checkpoint);
   }
 
   // Output results
-  if (args.json) {
-    console.log(formatJSON(results));
+  const groups =
+    args.groupBy === "owner" && owners
+      ? groupByOwner(results, owners)
+      : undefined;
+
+  if (format === "json") {
+    console.log(
+      formatJSON(results, {
+        owners,
+        shard: args.shard,
+        stopped: stoppedRun,
+        rootDir: args.shard ? repoRoot : undefined,
+      }),
+    );
+  } else if (format === "sarif") {
+    console.log(
+      formatSARIF(results, { rootDir: cwd, owners, stopped: stoppedRun }),
+    );
+  } else if (format === "markdown") {
+    console.log(
+      formatMarkdown(results, { rootDir: cwd, groups, stopped: stoppedRun }),
+    );
   } else {
+    if (groups) {
+      console.log(
+        formatOwnerGroups(groups, {
+          useColor: true,
+          showMetrics: config.debug,
+        }),
+      );
+    }
+
     // Print summary
     const bySeverity: Record<string, number> = {};
     let totalFindings = 0;
@@ -122,9 +547,17 @@ export async function runCLI(args: CLIArgs): Promise<number> {
         useColor: true,
       }),
     );
+
+    if (stoppedRun) {
+      console.log(formatStopped(stoppedRun, { useColor: true }));
+    }
   }
 
   // Determine exit code
+  if (stoppedRun) {
+    return stoppedRun.reason === "interrupted" ? EXIT_INTERRUPTED : EXIT_BUDGET;
+  }
+
   const hasErrors = Array.from(results.values()).some((r) =>
     r.findings.some((f) => f.severity === "error"),
   );
@@ -137,10 +570,17 @@ export async function runCLI(args: CLIArgs): Promise<number> {
   return 0;
 }
 
-async function resolveFiles(
+/**
+ * Find files to analyze. With a select function, every file is collected,
+ * then filtered and ordered by it before the list is cut to maxFiles.
+ * Files matching an include glob are found whatever their extension.
+ */
+export async function resolveFiles(
   paths: string[],
   extensions: string[],
   maxFiles: number,
+  select?: (files: string[]) => string[],
+  include: string[] = [],
 ): Promise<string[]> {
   const files: string[] = [];
   const extSet = new Set(
@@ -159,26 +599,82 @@ async function resolveFiles(
 
     if (stat.isFile()) {
       const ext = extname(resolved);
-      if (extSet.has(ext)) {
+      if (extSet.has(ext) || findMatchingGlob(inputPath, include)) {
         files.push(resolved);
       }
     } else if (stat.isDirectory()) {
-      // Glob for files in directory
-      const pattern = `${resolved}/**/*.{${extensions.join(",")}}`;
-      const matches = await glob(pattern, {
-        ignore: ["**/node_modules/**", "**/dist/**", "**/.git/**"],
-        absolute: true,
-      });
-      files.push(...matches);
+      // Glob for files in directory; a brace needs two or more alternatives
+      const exts = extensions.map((e) => e.replace(/^\./, ""));
+      const pattern =
+        exts.length === 1
+          ? `${resolved}/**/*.${exts[0]}`
+          : `${resolved}/**/*.{${exts.join(",")}}`;
+      const matches = await glob(
+        [
+          pattern,
+          // As in config paths, a glob without a slash matches at any depth
+          ...include.map((p) =>
+            p.includes("/") ? `${resolved}/${p}` : `${resolved}/**/${p}`,
+          ),
+        ],
+        {
+          ignore: ["**/node_modules/**", "**/dist/**", "**/.git/**"],
+          absolute: true,
+          nodir: true,
+        },
+      );
+      files.push(...new Set(matches));
     }
 
-    if (files.length >= maxFiles) {
+    if (!select && files.length >= maxFiles) {
       logger.warn(`Reached max files limit (${maxFiles})`);
       break;
     }
   }
 
+  if (select) {
+    const selected = select(files);
+    if (selected.length > maxFiles) {
+      logger.warn(`Reached max files limit (${maxFiles})`);
+    }
+    return selected.slice(0, maxFiles);
+  }
+
   return files.slice(0, maxFiles);
 }
 
+/**
+ * Filter and order files listed from git, then cut the list to maxFiles.
+ */
+function selectRevisionFiles(
+  files: string[],
+  maxFiles: number,
+  select: (files: string[]) => string[],
+): string[] {
+  const selected = select(files);
+  if (selected.length > maxFiles) {
+    logger.warn(`Reached max files limit (${maxFiles})`);
+  }
+  return selected.slice(0, maxFiles);
+}
+
+/**
+ * Put files with the most untested lines first. Falls back to the original
+ * order if the reports cannot be read.
+ */
+function orderByCoverage(
+  files: string[],
+  reports: string[],
+  cwd: string,
+): string[] {
+  try {
+    return orderByUncovered(files, loadCoverageReports(reports, cwd), cwd);
+  } catch (error) {
+    logger.warn(
+      `Cannot read coverage report: ${error instanceof Error ? error.message : error}`,
+    );
+    return files;
+  }
+}
+
 export { initConfig };
@@ -0,0 +1,152 @@
+import { readFileSync, writeFileSync } from "node:fs";
+import { resolve } from "node:path";
+import type { AnalysisResult } from "../core/analyzer.js";
+import { logger } from "../utils/logger.js";
+import { getRepoRoot } from "../utils/git.js";
+import {
+  formatJSON,
+  formatMarkdown,
+  summarizeJSONFiles,
+  type JSONOutput,
+  type StoppedRun,
+} from "./formatter.js";
+import { formatSARIF } from "./sarif.js";
+
+export interface MergeArgs {
+  inputs: string[]; // JSON reports, e.g. from --shard runs
+  output?: string; // Write here instead of stdout
+  format: "json" | "markdown" | "sarif";
+}
+
+/**
+ * Combine JSON reports into one with a recomputed summary. A file in
+ * several reports keeps its last result. Returns warnings for duplicate
+ * files, missing or inconsistent shards and runs that stopped early.
+ */
+export function mergeReports(reports: JSONOutput[]): {
+  merged: JSONOutput;
+  warnings: string[];
+} {
+  const warnings: string[] = [];
+  const files = new Map<string, JSONOutput["files"][number]>();
+
+  for (const report of reports) {
+    for (const file of report.files) {
+      if (files.has(file.path)) {
+        warnings.push(`${file.path} is in more than one report`);
+      }
+      files.set(file.path, file);
+    }
+  }
+
+  const shards = reports.flatMap((r) => (r.shard ? [r.shard] : []));
+  if (shards.length > 0) {
+    const counts = new Set(shards.map((s) => s.count));
+    if (counts.size > 1 || shards.length < reports.length) {
+      warnings.push("Reports come from runs with different --shard counts");
+    } else {
+      const [count] = counts;
+      const seen = new Set(shards.map((s) => s.index));
+      const missing = Array.from({ length: count }, (_, i) => i + 1).filter(
+        (index) => !seen.has(index),
+      );
+      if (missing.length > 0) {
+        warnings.push(`Missing shard(s) ${missing.join(", ")} of ${count}`);
+      }
+    }
+  }
+
+  const mergedFiles = Array.from(files.values());
+  const merged: JSONOutput = {
+    files: mergedFiles,
+    summary: summarizeJSONFiles(mergedFiles),
+  };
+
+  const incomplete = reports.filter((r) => r.incomplete);
+  if (incomplete.length > 0) {
+    warnings.push("Some reports come from runs that stopped early");
+    merged.incomplete = true;
+    merged.stopReason = incomplete.find((r) => r.stopReason)?.stopReason;
+    merged.skipped = incomplete
+      .flatMap((r) => r.skipped ?? [])
+      .filter((path) => !files.has(path));
+  }
+
+  return { merged, warnings };
+}
+
+/**
+ * Merge JSON reports from sharded runs and print or write the result.
+ * Shard reports have paths relative to the repository root, which is
+ * where they are resolved.
+ */
+export function runMerge(args: MergeArgs): number {
+  const cwd = process.cwd();
+  const root = getRepoRoot(cwd) ?? cwd;
+  const reports: JSONOutput[] = [];
+
+  for (const input of args.inputs) {
+    try {
+      const report = JSON.parse(readFileSync(resolve(cwd, input), "utf-8"));
+      if (!Array.isArray(report?.files)) {
+        throw new Error("not a lintai JSON report");
+      }
+      reports.push(report);
+    } catch (error) {
+      console.error(
+        `Error: Cannot read report ${input}: ${error instanceof Error ? error.message : error}`,
+      );
+      return 2;
+    }
+  }
+
+  const { merged, warnings } = mergeReports(reports);
+  for (const warning of warnings) {
+    logger.warn(warning);
+  }
+
+  const results = new Map<string, AnalysisResult>(
+    merged.files.map((file) => [
+      resolve(root, file.path),
+      {
+        findings: file.findings,
+        error: file.error,
+        skipped: file.skipped,
+        cached: false,
+      },
+    ]),
+  );
+  const owners = new Map(
+    merged.files.flatMap((file) =>
+      file.owners ? [[resolve(root, file.path), file.owners] as const] : [],
+    ),
+  );
+
+  const stopped: StoppedRun | undefined = merged.incomplete
+    ? {
+        reason: merged.stopReason ?? "interrupted",
+        skipped: (merged.skipped ?? []).map((path) => resolve(root, path)),
+      }
+    : undefined;
+
+  let text: string;
+  if (args.format === "sarif") {
+    text = formatSARIF(results, { rootDir: root, owners, stopped });
+  } else if (args.format === "markdown") {
+    text = formatMarkdown(results, { rootDir: root, stopped });
+  } else {
+    text = formatJSON(results, { owners, stopped, rootDir: root });
+  }
+
+  if (args.output) {
+    writeFileSync(resolve(cwd, args.output), text);
+    logger.info(
+      `Merged ${reports.length} report(s), ${merged.summary.totalFiles} file(s) into ${args.output}`,
+    );
+  } else {
+    console.log(text);
+  }
+
+  const { bySeverity } = merged.summary;
+  return bySeverity["error"] || bySeverity["warning"] ? 1 : 0;
+}
@@ -0,0 +1,18 @@
+/**
+ * Parse a whole-number option such as --max-age 30. Throws if the value is
+ * not a non-negative integer, or with positive set, is 0.
+ */
+export function parseIntegerOption(
+  value: string,
+  flag: string,
+  positive = false,
+): number {
+  const text = value.trim();
+  const min = positive ? 1 : 0;
+  if (!/^\d+$/.test(text) || Number(text) < min) {
+    throw new Error(
+      `Invalid ${flag} "${value}", expected a ${positive ? "positive" : "non-negative"} integer`,
+    );
+  }
+  return Number(text);
+}
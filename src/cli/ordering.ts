@@ -0,0 +1,73 @@
+import { statSync } from "node:fs";
+import type { FileOrder } from "../types/config.js";
+import { getFileChurn, getLastModified, getRepoRoot } from "../utils/git.js";
+import { logger } from "../utils/logger.js";
+
+// History window for the churn order
+const CHURN_DAYS = 90;
+
+/**
+ * Order files by the chosen strategy, most interesting first. Git-based
+ * orders fall back to the original order outside a repository.
+ */
+export function orderFiles(
+  files: string[],
+  order: FileOrder,
+  options: { cwd: string; seed: number },
+): string[] {
+  switch (order) {
+    case "churn":
+    case "recent": {
+      const repoRoot = getRepoRoot(options.cwd);
+      if (!repoRoot) {
+        logger.warn(`Not a git repository, ignoring --order ${order}`);
+        return files;
+      }
+      try {
+        const score =
+          order === "churn"
+            ? getFileChurn(repoRoot, CHURN_DAYS)
+            : getLastModified(repoRoot);
+        return sortByScore(files, (f) => score.get(f) ?? 0);
+      } catch (error) {
+        logger.warn(
+          `git failed, ignoring --order ${order}: ${error instanceof Error ? error.message : error}`,
+        );
+        return files;
+      }
+    }
+    case "size":
+      return sortByScore(files, (f) => statSync(f).size);
+    case "random-seeded":
+      return shuffle([...files].sort(), options.seed);
+    default:
+      return files;
+  }
+}
+
+// Highest score first; ties keep their order
+function sortByScore(files: string[], scoreOf: (f: string) => number) {
+  const scores = new Map(files.map((f) => [f, scoreOf(f)]));
+  return [...files].sort((a, b) => scores.get(b)! - scores.get(a)!);
+}
+
+/**
+ * Fisher-Yates shuffle with a seeded PRNG (mulberry32), so the same seed
+ * picks the same sample of files on every run.
+ */
+function shuffle(files: string[], seed: number): string[] {
+  let state = seed >>> 0;
+  const random = () => {
+    state = (state + 0x6d2b79f5) >>> 0;
+    let t = state;
+    t = Math.imul(t ^ (t >>> 15), t | 1);
+    t ^= t + Math.imul(t ^ (t >>> 7), t | 61);
+    return ((t ^ (t >>> 14)) >>> 0) / 4294967296;
+  };
+
+  for (let i = files.length - 1; i > 0; i--) {
+    const j = Math.floor(random() * (i + 1));
+    [files[i], files[j]] = [files[j], files[i]];
+  }
+  return files;
+}
@@ -0,0 +1,79 @@
+import { extname } from "node:path";
+import {
+  getRepoRoot,
+  listRangeFiles,
+  listTreeFiles,
+  readBlob,
+  type RevisionFile,
+} from "../utils/git.js";
+import { findMatchingGlob } from "../utils/glob-match.js";
+
+// Same directories the work tree glob skips
+const IGNORED_DIRS = /(^|\/)(node_modules|dist|\.git)\//;
+
+/**
+ * Files read from git objects instead of the work tree, keyed by the name
+ * they get in results.
+ */
+export interface RevisionSource {
+  files: Map<string, RevisionFile>;
+  read(filePath: string): string;
+}
+
+/**
+ * Files to analyze from `--rev <commit>` (the tree of one commit) or
+ * `--range A..B` (every version added or modified in the range). Versions
+ * from a range are named path@commit, so one file can appear once per
+ * change. Throws if cwd is not in a repository or git rejects the revision.
+ */
+export function loadRevisionFiles(options: {
+  rev?: string;
+  range?: string;
+  paths: string[];
+  extensions: string[];
+  include?: string[];
+  cwd: string;
+}): RevisionSource {
+  const { rev, range, paths, cwd } = options;
+  const repoRoot = getRepoRoot(cwd);
+  if (!repoRoot) {
+    throw new Error("--rev and --range need a git repository");
+  }
+
+  const extSet = new Set(
+    options.extensions.map((e) => (e.startsWith(".") ? e : `.${e}`)),
+  );
+  const listed = range
+    ? listRangeFiles(repoRoot, range, paths, cwd)
+    : listTreeFiles(repoRoot, rev ?? "HEAD", paths, cwd);
+
+  const files = new Map<string, RevisionFile>();
+  for (const file of listed) {
+    const relPath = file.path.slice(repoRoot.length + 1);
+    const wanted =
+      extSet.has(extname(file.path)) ||
+      findMatchingGlob(relPath, options.include ?? []);
+    if (!wanted || IGNORED_DIRS.test(relPath)) {
+      continue;
+    }
+    const name = range ? `${file.path}@${file.commit.slice(0, 7)}` : file.path;
+    files.set(name, file);
+  }
+
+  const contents = new Map<string, string>();
+  return {
+    files,
+    read(filePath) {
+      const file = files.get(filePath);
+      if (!file) {
+        throw new Error(`${filePath} is not part of the revision`);
+      }
+      let content = contents.get(file.blob);
+      if (content === undefined) {
+        content = readBlob(file.blob, repoRoot);
+        contents.set(file.blob, content);
+      }
+      return content;
+    },
+  };
+}
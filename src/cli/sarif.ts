@@ -0,0 +1,203 @@
+/**
+ * SARIF 2.1.0 output for analysis results and triaged diagnostics. For
+ * triage, each input tool becomes a run; verdicts are attached to results as
+ * properties and a rank.
+ */
+
+import { relative } from "node:path";
+import type { AnalysisResult } from "../core/analyzer.js";
+import type { TriageGroup, TriagePriority } from "../core/triage.js";
+import { diagnosticPath } from "../core/external-results.js";
+import { categoryToString } from "../core/diagnostics-mapper.js";
+import type { FindingSeverity } from "../types/finding.js";
+import type { StoppedRun } from "./formatter.js";
+
+const SARIF_SCHEMA = "https://json.schemastore.org/sarif-2.1.0.json";
+
+// SARIF rank is 0-100, higher is more important
+const PRIORITY_RANK: Record<TriagePriority, number> = {
+  high: 90,
+  medium: 50,
+  low: 10,
+};
+
+const SARIF_LEVELS = new Set(["error", "warning", "note", "none"]);
+
+const SEVERITY_LEVELS: Record<FindingSeverity, string> = {
+  error: "error",
+  warning: "warning",
+  info: "note",
+  hint: "note",
+};
+
+export interface SARIFOptions {
+  rootDir: string;
+  owners?: Map<string, string[]>;
+  stopped?: StoppedRun;
+}
+
+/**
+ * Analysis results as a single lintai run. Finding IDs are chosen by the
+ * model, so the category is the rule. Owners from CODEOWNERS are attached to
+ * each result as the lintai/owners property.
+ */
+export function formatSARIF(
+  results: Map<string, AnalysisResult>,
+  options: SARIFOptions,
+): string {
+  const { rootDir, owners, stopped } = options;
+  const rules = new Map<string, { id: string; name: string }>();
+  const sarifResults: unknown[] = [];
+
+  for (const [filePath, result] of results) {
+    const uri = relative(rootDir, filePath).replace(/\\/g, "/");
+
+    for (const finding of result.findings) {
+      rules.set(finding.category, {
+        id: finding.category,
+        name: categoryToString(finding.category),
+      });
+
+      const region = finding.range && {
+        startLine: finding.range.startLine + 1,
+        startColumn: finding.range.startCharacter + 1,
+        endLine: finding.range.endLine + 1,
+        endColumn: finding.range.endCharacter + 1,
+      };
+
+      sarifResults.push({
+        ruleId: finding.category,
+        level: SEVERITY_LEVELS[finding.severity],
+        message: {
+          text: finding.suggestion
+            ? `${finding.title}: ${finding.message}\n\n${finding.suggestion}`
+            : `${finding.title}: ${finding.message}`,
+        },
+        locations: [
+          {
+            // Lines of a notebook cell are not lines of the .ipynb file
+            physicalLocation: {
+              artifactLocation: { uri },
+              region: finding.cell === undefined ? region : undefined,
+            },
+            logicalLocations:
+              finding.cell !== undefined
+                ? [{ name: `cell ${finding.cell}`, kind: "notebookCell" }]
+                : undefined,
+          },
+        ],
+        properties: {
+          "lintai/id": finding.id,
+          "lintai/confidence": finding.confidence,
+          "lintai/owners": owners?.get(filePath),
+          "lintai/blame": finding.blame,
+          "lintai/cell": finding.cell,
+          "lintai/cellRegion": finding.cell !== undefined ? region : undefined,
+        },
+      });
+    }
+  }
+
+  const log = {
+    $schema: SARIF_SCHEMA,
+    version: "2.1.0",
+    runs: [
+      {
+        tool: {
+          driver: {
+            name: "lintai",
+            informationUri: "https://github.com/maxischmaxi/lintai",
+            rules: Array.from(rules.values()),
+          },
+        },
+        invocations: [
+          {
+            executionSuccessful: !stopped,
+            properties: stopped && {
+              "lintai/stopReason": stopped.reason,
+              "lintai/skipped": stopped.skipped.map((path) =>
+                relative(rootDir, path).replace(/\\/g, "/"),
+              ),
+            },
+          },
+        ],
+        // Finding columns count characters, not the UTF-16 default
+        columnKind: "unicodeCodePoints",
+        results: sarifResults,
+      },
+    ],
+  };
+
+  return JSON.stringify(log, null, 2);
+}
+
+export function formatTriageSARIF(
+  groups: TriageGroup[],
+  rootDir: string,
+): string {
+  const runs = new Map<string, { rules: Set<string>; results: unknown[] }>();
+
+  for (const group of groups) {
+    const run = runs.get(group.source) ?? { rules: new Set(), results: [] };
+    runs.set(group.source, run);
+    if (group.rule) run.rules.add(group.rule);
+
+    for (const diagnostic of group.diagnostics) {
+      const falsePositive =
+        group.falsePositives.has(diagnostic) ||
+        group.likelyFalsePositive === true;
+
+      run.results.push({
+        ruleId: diagnostic.rule,
+        level: toSarifLevel(diagnostic.level),
+        message: { text: diagnostic.message },
+        locations: [
+          {
+            physicalLocation: {
+              artifactLocation: { uri: diagnosticPath(diagnostic, rootDir) },
+              region: {
+                startLine: diagnostic.line,
+                endLine: diagnostic.endLine,
+                startColumn: diagnostic.column,
+              },
+            },
+          },
+        ],
+        rank: group.priority ? PRIORITY_RANK[group.priority] : undefined,
+        properties: {
+          "lintai/group": group.id,
+          "lintai/priority": group.priority,
+          "lintai/explanation": group.explanation,
+          "lintai/likelyFalsePositive": falsePositive,
+        },
+      });
+    }
+  }
+
+  const log = {
+    $schema: SARIF_SCHEMA,
+    version: "2.1.0",
+    runs: Array.from(runs, ([source, run]) => ({
+      tool: {
+        driver: {
+          name: source,
+          rules: Array.from(run.rules, (id) => ({ id })),
+        },
+      },
+      results: run.results,
+    })),
+  };
+
+  return JSON.stringify(log, null, 2);
+}
+
+function toSarifLevel(level: string | undefined): string {
+  if (!level) {
+    return "warning";
+  }
+  const normalized = level.toLowerCase();
+  if (SARIF_LEVELS.has(normalized)) {
+    return normalized;
+  }
+  return normalized === "info" ? "note" : "warning";
+}
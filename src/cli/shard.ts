@@ -0,0 +1,50 @@
+import { relative } from "node:path";
+import { computeHash } from "../utils/hash.js";
+
+export interface Shard {
+  index: number; // 1-based
+  count: number;
+}
+
+/**
+ * Parse "i/n", e.g. "2/4" for the second of four shards.
+ */
+export function parseShard(value: string): Shard {
+  const match = /^(\d+)\/(\d+)$/.exec(value.trim());
+  if (!match) {
+    throw new Error(`Invalid shard "${value}", expected i/n (e.g. 1/4)`);
+  }
+
+  const index = Number(match[1]);
+  const count = Number(match[2]);
+  if (count < 1 || index < 1 || index > count) {
+    throw new Error(`Invalid shard "${value}", i must be between 1 and n`);
+  }
+  return { index, count };
+}
+
+/**
+ * Shard a file belongs to. It depends only on the file's path relative to
+ * rootDir, so adding or removing other files never moves it.
+ */
+export function shardOf(
+  filePath: string,
+  count: number,
+  rootDir: string,
+): number {
+  const key = relative(rootDir, filePath).replace(/\\/g, "/");
+  return (parseInt(computeHash(key).slice(0, 8), 16) % count) + 1;
+}
+
+/**
+ * Keep the files that belong to the shard, in their original order.
+ */
+export function selectShard(
+  files: string[],
+  shard: Shard,
+  rootDir: string,
+): string[] {
+  return files.filter(
+    (filePath) => shardOf(filePath, shard.count, rootDir) === shard.index,
+  );
+}
@@ -0,0 +1,99 @@
+import { writeFileSync } from "node:fs";
+import { resolve } from "node:path";
+import { loadConfig, validateAPIKey } from "../config/loader.js";
+import { resolveLLMConfig } from "../types/config.js";
+import { createRedactor } from "../core/redactor.js";
+import {
+  loadExternalResults,
+  type ExternalDiagnostic,
+} from "../core/external-results.js";
+import { sortTriageGroups, triageDiagnostics } from "../core/triage.js";
+import { logger } from "../utils/logger.js";
+import { formatTriageJSON, formatTriageReport } from "./formatter.js";
+import { formatTriageSARIF } from "./sarif.js";
+
+export interface TriageArgs {
+  results: string[];
+  config?: string;
+  debug: boolean;
+  json: boolean;
+  output?: string; // Path for the annotated SARIF
+  maxGroups: number;
+  top: number;
+}
+
+/**
+ * Group, prioritize and explain diagnostics from other linters.
+ */
+export async function runTriage(args: TriageArgs): Promise<number> {
+  const cwd = process.cwd();
+  const config = loadConfig(cwd, { config: args.config, debug: args.debug });
+
+  if (config.debug) {
+    logger.setLevel("debug");
+  }
+
+  const apiKeyValidation = validateAPIKey(config);
+  if (!apiKeyValidation.valid) {
+    console.error(`Error: ${apiKeyValidation.message}`);
+    return 2;
+  }
+
+  const diagnostics: ExternalDiagnostic[] = [];
+  for (const resultsPath of args.results) {
+    try {
+      diagnostics.push(...loadExternalResults(resultsPath, cwd));
+    } catch (error) {
+      console.error(
+        `Error: Cannot read results from ${resultsPath}: ${error instanceof Error ? error.message : error}`,
+      );
+      return 2;
+    }
+  }
+
+  if (diagnostics.length === 0) {
+    console.error("No diagnostics found in the results");
+    return 2;
+  }
+
+  if (!args.json) {
+    console.log(`Triaging ${diagnostics.length} diagnostic(s)...`);
+  }
+
+  const llm = resolveLLMConfig(config.llm);
+  const result = await triageDiagnostics({
+    diagnostics,
+    rootDir: cwd,
+    config,
+    llm,
+    redactor: createRedactor(config.privacy),
+    maxGroups: args.maxGroups,
+    onBatch: (done, total) => logger.debug(`Triaged batch ${done}/${total}`),
+  });
+  const groups = sortTriageGroups(result.groups);
+
+  if (args.output) {
+    writeFileSync(resolve(cwd, args.output), formatTriageSARIF(groups, cwd));
+  }
+
+  if (args.json) {
+    console.log(formatTriageJSON(groups, cwd));
+  } else {
+    console.log("");
+    console.log(
+      formatTriageReport(groups, {
+        useColor: true,
+        rootDir: cwd,
+        top: args.top,
+      }),
+    );
+    console.log(
+      `${groups.length} group(s), ${result.triaged} triaged by ${llm.provider}`,
+    );
+    if (args.output) {
+      console.log(`Wrote annotated SARIF to ${args.output}`);
+    }
+  }
+
+  return result.triaged > 0 ? 0 : 2;
+}
@@ -11,6 +11,32 @@ export const DEFAULT_CONFIG: AilintConfig = {
     mode: "snippet",
     includeImports: false,
     maxFileSize: 100000,
+    skipGenerated: true,
+    unit: "file",
+    maxUnitTokens: 16000,
+    tools: {
+      enabled: false,
+      maxCalls: 8,
+      maxResultTokens: 1500,
+      maxTotalTokens: 8000,
+    },
+    retrieval: {
+      enabled: false,
+      baseUrl: "http://localhost:11434",
+      model: "nomic-embed-text",
+      indexPath: ".lintai/index.json",
+      topK: 3,
+      minScore: 0.5,
+      maxTokens: 2000,
+    },
+    verify: {
+      enabled: false,
+      minSeverity: "warning",
+    },
+    coverage: {
+      reports: [],
+      prioritize: false,
+    },
   },
   rules: {
     codeSmells: true,
@@ -19,6 +45,8 @@ export const DEFAULT_CONFIG: AilintConfig = {
     naming: true,
     errorHandling: true,
     anyAbuse: true,
+    documentation: false,
+    frameworks: true,
   },
   severity: {
     highConfidenceThreshold: 0.8,
@@ -34,6 +62,19 @@ export const DEFAULT_CONFIG: AilintConfig = {
     format: "human",
     maxFiles: 100,
     extensions: ["ts", "tsx", "js", "jsx", "go"],
+    include: [],
+    order: "default",
+    seed: 0,
+    blame: false,
+  },
+  privacy: {
+    redactSecrets: true,
+    redactPatterns: [],
+    allowRemoteProviders: true,
+    localOnlyPaths: [],
+  },
+  audit: {
+    enabled: true,
   },
   debug: false,
 };
@@ -4,7 +4,9 @@ import { homedir } from "node:os";
 import {
   AilintConfigSchema,
   type AilintConfig,
+  type FileOrder,
   type LLMProvider,
+  type OutputFormat,
   PROVIDER_DEFAULTS,
   resolveLLMConfig,
   type ResolvedLLMConfig,
@@ -19,10 +21,16 @@ import { logger, computeHash } from "../utils/index.js";
 interface CLIOptions {
   config?: string;
   debug?: boolean;
-  format?: "human" | "json";
+  format?: OutputFormat;
   model?: string;
   baseUrl?: string;
   provider?: LLMProvider;
+  coverage?: string[]; // Coverage reports, replacing the configured ones
+  order?: FileOrder;
+  seed?: number;
+  blame?: boolean;
+  extensions?: string[];
+  include?: string[]; // Added to the configured patterns
 }
 
 function findConfigFile(startDir: string): string | null {
@@ -74,6 +82,67 @@ function getUserConfigPath(): string | null {
   return null;
 }
 
+/**
+ * Read a value at a dotted path (e.g. "privacy.localOnlyPaths").
+ */
+function getPath(obj: Record<string, unknown>, path: string): unknown {
+  let current: unknown = obj;
+  for (const key of path.split(".")) {
+    if (typeof current !== "object" || current === null) {
+      return undefined;
+    }
+    current = (current as Record<string, unknown>)[key];
+  }
+  return current;
+}
+
+/**
+ * Return a copy of obj with the value at a dotted path replaced.
+ */
+function setPath<T extends Record<string, unknown>>(
+  obj: T,
+  path: string,
+  value: unknown,
+): T {
+  const [key, ...rest] = path.split(".");
+  const result: Record<string, unknown> = { ...obj };
+  if (rest.length === 0) {
+    result[key] = value;
+  } else {
+    const child = result[key];
+    result[key] = setPath(
+      typeof child === "object" && child !== null
+        ? (child as Record<string, unknown>)
+        : {},
+      rest.join("."),
+      value,
+    );
+  }
+  return result as T;
+}
+
+/**
+ * Re-apply settings locked by the user (org) config, so project config,
+ * environment variables and CLI flags cannot override them.
+ */
+function enforceLockedConfig(
+  config: AilintConfig,
+  locked: Map<string, unknown>,
+  source: string,
+): AilintConfig {
+  let result = config;
+
+  for (const [path, value] of locked) {
+    const current = getPath(result, path);
+    if (JSON.stringify(current) !== JSON.stringify(value)) {
+      logger.warn(`Ignoring override of "${path}": locked by ${source}`);
+      result = setPath(result, path, value);
+    }
+  }
+
+  return result;
+}
+
 function getAPIKeyFromEnv(provider: LLMProvider): string | undefined {
   const envVars = ENV_VAR_MAPPINGS[provider] || ["AILINT_API_KEY"];
 
@@ -137,6 +206,25 @@ function applyCLIOptions(
     result.cli = { ...result.cli, format: options.format };
   }
 
+  if (
+    options.order ||
+    options.seed !== undefined ||
+    options.blame ||
+    options.extensions?.length ||
+    options.include?.length
+  ) {
+    result.cli = {
+      ...result.cli,
+      ...(options.order && { order: options.order }),
+      ...(options.seed !== undefined && { seed: options.seed }),
+      ...(options.blame && { blame: true }),
+      ...(options.extensions?.length && { extensions: options.extensions }),
+      ...(options.include?.length && {
+        include: [...result.cli.include, ...options.include],
+      }),
+    };
+  }
+
   if (options.model || options.baseUrl || options.provider) {
     result.llm = {
       ...result.llm,
@@ -146,6 +234,13 @@ function applyCLIOptions(
     };
   }
 
+  if (options.coverage && options.coverage.length > 0) {
+    result.analysis = {
+      ...result.analysis,
+      coverage: { ...result.analysis.coverage, reports: options.coverage },
+    };
+  }
+
   return result;
 }
 
@@ -188,11 +283,19 @@ export function loadConfig(
   let config: AilintConfig = { ...DEFAULT_CONFIG };
 
   // Step 1: Load user config from ~/.config/lintai/lintai.json
+  // Its "locked" list names settings that later sources may not override
+  const locked = new Map<string, unknown>();
   const userConfigPath = getUserConfigPath();
   if (userConfigPath) {
-    const userConfig = loadConfigFile(userConfigPath);
+    const { locked: lockedPaths, ...userConfig } = loadConfigFile(
+      userConfigPath,
+    ) as Partial<AilintConfig> & { locked?: string[] };
     config = deepMerge(config, userConfig);
     logger.debug("Applied user config");
+
+    for (const path of Array.isArray(lockedPaths) ? lockedPaths : []) {
+      locked.set(path, getPath(config, path));
+    }
   }
 
   // Step 2: Load project config (if specified or found)
@@ -213,6 +316,20 @@ export function loadConfig(
   // Apply CLI options (highest priority)
   config = applyCLIOptions(config, options);
 
+  // A verifier on another provider needs that provider's key
+  const verify = config.analysis.verify;
+  if (verify.provider && verify.provider !== config.llm.provider) {
+    const verifyKey = verify.apiKey ?? getAPIKeyFromEnv(verify.provider);
+    if (verifyKey) {
+      config = setPath(config, "analysis.verify.apiKey", verifyKey);
+    }
+  }
+
+  // Locked user settings win over everything else
+  if (userConfigPath && locked.size > 0) {
+    config = enforceLockedConfig(config, locked, userConfigPath);
+  }
+
   // Validate final config
   const validated = AilintConfigSchema.safeParse(config);
   if (!validated.success) {
@@ -231,6 +348,7 @@ export function getConfigHash(config: AilintConfig): string {
     llm: { model: resolved.model, provider: resolved.provider },
     analysis: config.analysis,
     rules: config.rules,
+    privacy: config.privacy,
   };
   return computeHash(JSON.stringify(relevantConfig));
 }
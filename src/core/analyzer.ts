@@ -1,15 +1,69 @@
+import { resolve } from "node:path";
 import type { Finding } from "../types/finding.js";
 import type { AilintConfig } from "../types/config.js";
-import { resolveLLMConfig } from "../types/config.js";
-import { buildSystemPrompt, buildUserPrompt } from "../llm/prompt-builder.js";
-import { sendLLMRequest, LLMError } from "../llm/client.js";
+import { resolveLLMConfig, resolveVerifierConfig } from "../types/config.js";
+import {
+  buildSystemPrompt,
+  buildUnitUserPrompt,
+  buildUserPrompt,
+} from "../llm/prompt-builder.js";
+import {
+  sendLLMRequest,
+  sendLLMRequestWithTools,
+  supportsToolCalling,
+  LLMError,
+  RequestBudgetError,
+  type LLMRequestOptions,
+} from "../llm/client.js";
+import { resolveAuditLogPath } from "../llm/audit-log.js";
 import { parseResponse } from "../llm/response-parser.js";
 import { logger } from "../utils/logger.js";
-import { getLanguageForExtension } from "./languages.js";
+import {
+  detectProjectFacts,
+  getLanguageForFile,
+  getLanguageForLanguageId,
+  sqlDialectFacts,
+} from "./languages.js";
+import {
+  createRedactor,
+  type RedactedText,
+  type Redactor,
+} from "./redactor.js";
+import {
+  checkEgressPolicy,
+  isLocalProvider,
+  toWorkspacePath,
+} from "./egress-policy.js";
+import { CONTEXT_TOOLS, createContextToolExecutor } from "./context-tools.js";
+import {
+  retrieveRelatedCode,
+  type RelatedSnippet,
+} from "./embedding-index.js";
+import { verifyFindings } from "./verifier.js";
+import { detectFrameworks, type FrameworkConfig } from "./frameworks.js";
+import { matchUnitFile } from "./units.js";
+import { normalizeRange, splitLines } from "./positions.js";
+import {
+  isNotebook,
+  mapToCell,
+  notebookToDocument,
+  type NotebookDocument,
+} from "./notebooks.js";
+import {
+  dropDuplicateFindings,
+  type ExternalDiagnostic,
+} from "./external-results.js";
+import {
+  findFileCoverage,
+  loadCoverageReports,
+  summarizeCoverage,
+  type CoverageSummary,
+} from "./coverage.js";
 
 export interface AnalysisResult {
   findings: Finding[];
   error?: string;
+  skipped?: string; // Why the file was not analyzed, e.g. generated code
   cached: boolean;
   metrics?: {
     llmTimeMs: number;
@@ -21,7 +75,13 @@ export interface AnalyzeOptions {
   filePath: string;
   content: string;
   config: AilintConfig;
+  languageId?: string; // LSP language of the document, wins over the extension
+  rootDir?: string; // Workspace root for path-based policies (default: cwd)
   skipLLM?: boolean;
+  knownIssues?: ExternalDiagnostic[]; // Diagnostics from other linters
+  // The path is not a workspace path, so path policies cannot be checked;
+  // nothing about the document may go to a remote provider
+  localOnly?: boolean;
 }
 
 /**
@@ -29,9 +89,65 @@ export interface AnalyzeOptions {
  */
 export async function analyze(
   options: AnalyzeOptions,
+): Promise<AnalysisResult> {
+  return isNotebook(options.filePath)
+    ? analyzeNotebook(options)
+    : analyzeDocument(options);
+}
+
+/**
+ * Analyze the code cells of a notebook as one Python document and report
+ * findings by cell.
+ */
+async function analyzeNotebook(
+  options: AnalyzeOptions,
+): Promise<AnalysisResult> {
+  let notebook: NotebookDocument;
+  try {
+    notebook = notebookToDocument(options.content);
+  } catch (error) {
+    return {
+      findings: [],
+      error: `Cannot read notebook: ${error instanceof Error ? error.message : error}`,
+      cached: false,
+    };
+  }
+
+  if (notebook.cells.length === 0) {
+    return { findings: [], cached: false };
+  }
+
+  const result = await analyzeDocument({
+    ...options,
+    content: notebook.content,
+    languageId: "python",
+  });
+  return {
+    ...result,
+    findings: result.findings.map((f) => mapToCell(f, notebook)),
+  };
+}
+
+async function analyzeDocument(
+  options: AnalyzeOptions,
 ): Promise<AnalysisResult> {
   const startTime = Date.now();
-  const { filePath, content, config, skipLLM = false } = options;
+  const {
+    filePath,
+    content,
+    languageId,
+    rootDir = process.cwd(),
+    skipLLM = false,
+    knownIssues = [],
+    localOnly = false,
+  } = options;
+  // Verification, retrieval and tools then refuse remote endpoints too
+  const config = localOnly
+    ? {
+        ...options.config,
+        privacy: { ...options.config.privacy, allowRemoteProviders: false },
+      }
+    : options.config;
 
   // Check file size
   if (content.length > config.analysis.maxFileSize) {
@@ -42,9 +158,10 @@ export async function analyze(
     };
   }
 
-  // Detect language from file extension
-  const ext = filePath.slice(filePath.lastIndexOf("."));
-  const language = getLanguageForExtension(ext);
+  // Detect language from the editor's language id or the file
+  const language =
+    (languageId && getLanguageForLanguageId(languageId)) ||
+    getLanguageForFile(filePath, content);
 
   logger.debug("Analyzing file", {
     filePath,
@@ -64,27 +181,100 @@ export async function analyze(
     };
   }
 
-  // Build prompts
-  const systemPrompt = buildSystemPrompt(config.rules, language?.id);
-  const userPrompt = buildUserPrompt(filePath, content, language?.id);
-
   // Resolve LLM config with provider defaults
   const resolvedLLMConfig = resolveLLMConfig(config.llm);
 
+  // Refuse to send code the egress policy does not allow to leave the machine
+  const policyViolation =
+    localOnly && !isLocalProvider(resolvedLLMConfig)
+      ? `Refusing to send ${toWorkspacePath(filePath, rootDir)} to a remote provider: the document is not a workspace file, so privacy.localOnlyPaths cannot be checked`
+      : checkEgressPolicy(filePath, resolvedLLMConfig, config.privacy, rootDir);
+  if (policyViolation) {
+    logger.warn(policyViolation);
+    return {
+      findings: [],
+      error: policyViolation,
+      cached: false,
+    };
+  }
+
+  // Scrub secrets before the code leaves the machine
+  const redactor = createRedactor(config.privacy);
+  const redacted = redactor?.redact(content);
+  if (redacted && redacted.count > 0) {
+    logger.debug(`Redacted ${redacted.count} secret(s) in ${filePath}`);
+  }
+
+  // Let the model gather more context through tools if configured
+  const toolsEnabled =
+    config.analysis.tools.enabled &&
+    supportsToolCalling(resolvedLLMConfig.provider);
+
+  // Pull similar code from the local embedding index for consistency
+  let relatedCode: RelatedSnippet[] = [];
+  if (config.analysis.retrieval.enabled) {
+    try {
+      relatedCode = await retrieveRelatedCode({
+        filePath,
+        content,
+        languageId: language?.id,
+        rootDir,
+        config,
+        llm: resolvedLLMConfig,
+        redactor,
+      });
+    } catch (error) {
+      logger.warn(
+        `Retrieval failed, analyzing without related code: ${error instanceof Error ? error.message : error}`,
+      );
+    }
+  }
+
+  // Build prompts
+  const systemPrompt = buildSystemPrompt(config.rules, language?.id, {
+    toolsEnabled,
+    projectFacts: projectFactsFor(filePath, language?.id, config, rootDir),
+    frameworks: detectFrameworks(filePath, content, language?.id, rootDir),
+  });
+  const userPrompt = buildUserPrompt(
+    filePath,
+    redacted?.text ?? content,
+    language?.id,
+    {
+      relatedCode,
+      knownIssues: redactDiagnostics(knownIssues, redactor),
+      coverage: getCoverage(filePath, content, language?.id, config, rootDir),
+    },
+  );
+
   // Send to LLM
   const llmStartTime = Date.now();
 
   try {
-    const response = await sendLLMRequest({
+    const request: LLMRequestOptions = {
       systemPrompt,
       userPrompt,
       config: resolvedLLMConfig,
       rateLimitPerMinute: config.performance.rateLimitPerMinute,
       rateLimitEnabled: config.performance.rateLimitEnabled,
       requestId: filePath,
-    });
+      filePath,
+      contextFiles: relatedCode.map((s) => resolve(rootDir, s.file)),
+      auditLogPath: resolveAuditLogPath(config.audit),
+    };
 
-    const llmTimeMs = Date.now() - llmStartTime;
+    const response = toolsEnabled
+      ? await sendLLMRequestWithTools(request, {
+          tools: CONTEXT_TOOLS,
+          execute: createContextToolExecutor({
+            rootDir,
+            config,
+            llm: resolvedLLMConfig,
+            redactor,
+          }),
+          maxCalls: config.analysis.tools.maxCalls,
+        })
+      : await sendLLMRequest(request);
 
     // Parse response
     const parseResult = parseResponse(response.content);
@@ -93,8 +283,44 @@ export async function analyze(
       logger.warn("Response parse issue:", parseResult.parseError);
     }
 
+    // Drop what the project's linters already report
+    const deduped = dropDuplicateFindings(parseResult.findings, knownIssues);
+    if (deduped.dropped > 0) {
+      logger.debug(
+        `Dropped ${deduped.dropped} finding(s) reported by other tools`,
+      );
+    }
+
+    // Second opinion on findings to filter out confident but wrong ones
+    let parsedFindings = deduped.findings;
+    if (config.analysis.verify.enabled && parsedFindings.length > 0) {
+      const verification = await verifyFindings({
+        filePath,
+        content: redacted?.text ?? content,
+        findings: parsedFindings,
+        languageId: language?.id,
+        rootDir,
+        config,
+        llm: resolveVerifierConfig(config.llm, config.analysis.verify),
+      });
+      logger.debug(
+        `Verified ${verification.verified} finding(s), dropped ${verification.dropped}`,
+      );
+      parsedFindings = verification.findings;
+    }
+
+    const llmTimeMs = Date.now() - llmStartTime;
+
+    // Map placeholders in findings back to the original code
+    const findings = fitRanges(
+      redactor && redacted
+        ? parsedFindings.map((f) => redactor.restoreFinding(f, redacted))
+        : parsedFindings,
+      content,
+    );
+
     return {
-      findings: parseResult.findings,
+      findings,
       error: parseResult.parseError,
       cached: false,
       metrics: {
@@ -103,55 +329,361 @@ export async function analyze(
       },
     };
   } catch (error) {
-    const llmTimeMs = Date.now() - llmStartTime;
-
-    let errorMessage = "LLM request failed";
-
-    if (error instanceof LLMError) {
-      if (error.isAuthError()) {
-        errorMessage = `Invalid API key for ${resolvedLLMConfig.provider}. Check your API key configuration.`;
-      } else if (error.isServerError()) {
-        errorMessage = "LLM service temporarily unavailable.";
-      } else {
-        errorMessage = error.message;
-      }
-    } else if (error instanceof Error) {
-      if (error.name === "TimeoutError") {
-        logger.debug("LLM request timed out");
-        return {
-          findings: [],
-          error: "Analysis timed out",
-          cached: false,
-          metrics: {
-            llmTimeMs,
-            totalTimeMs: Date.now() - startTime,
-          },
-        };
-      } else if (error.message === "Request superseded by newer request") {
-        logger.debug("Request cancelled (superseded)");
-        return {
-          findings: [],
-          cached: false,
-          metrics: {
-            llmTimeMs,
-            totalTimeMs: Date.now() - startTime,
-          },
-        };
-      } else {
-        errorMessage = error.message;
-      }
-    }
-
-    logger.error("LLM error:", errorMessage);
-
     return {
       findings: [],
-      error: errorMessage,
+      error: describeLLMError(error, resolvedLLMConfig.provider),
       cached: false,
       metrics: {
-        llmTimeMs,
+        llmTimeMs: Date.now() - llmStartTime,
         totalTimeMs: Date.now() - startTime,
       },
     };
   }
 }
+
+export interface UnitFile {
+  filePath: string;
+  content: string;
+  knownIssues?: ExternalDiagnostic[];
+}
+
+export interface AnalyzeUnitOptions {
+  files: UnitFile[];
+  config: AilintConfig;
+  rootDir?: string;
+}
+
+/**
+ * Analyze several files of one package or module in a single request, so the
+ * model sees helpers, methods and state defined in sibling files. Findings
+ * are split back to their files.
+ */
+export async function analyzeUnit(
+  options: AnalyzeUnitOptions,
+): Promise<Map<string, AnalysisResult>> {
+  const startTime = Date.now();
+  const { files, config, rootDir = process.cwd() } = options;
+  const results = new Map<string, AnalysisResult>();
+
+  const resolvedLLMConfig = resolveLLMConfig(config.llm);
+  const redactor = createRedactor(config.privacy);
+
+  // Apply the same per-file checks as analyze()
+  const included: Array<
+    UnitFile & { relPath: string; redacted?: RedactedText }
+  > = [];
+  for (const file of files) {
+    // Notebooks are converted on their own, see analyzeNotebook()
+    if (isNotebook(file.filePath)) {
+      results.set(file.filePath, await analyze({ ...file, config, rootDir }));
+      continue;
+    }
+
+    let error: string | null = null;
+    if (file.content.length > config.analysis.maxFileSize) {
+      error = `File exceeds size limit (${Math.round(file.content.length / 1024)}KB > ${Math.round(config.analysis.maxFileSize / 1024)}KB)`;
+    } else {
+      error = checkEgressPolicy(
+        file.filePath,
+        resolvedLLMConfig,
+        config.privacy,
+        rootDir,
+      );
+    }
+
+    if (error) {
+      results.set(file.filePath, { findings: [], error, cached: false });
+      continue;
+    }
+
+    included.push({
+      ...file,
+      relPath: toWorkspacePath(file.filePath, rootDir),
+      redacted: redactor?.redact(file.content),
+    });
+  }
+
+  // Nothing to gain from a unit request for a single file
+  if (included.length <= 1) {
+    for (const file of included) {
+      results.set(file.filePath, await analyze({ ...file, config, rootDir }));
+    }
+    return results;
+  }
+
+  const language = getLanguageForFile(
+    included[0].filePath,
+    included[0].content,
+  );
+  const toolsEnabled =
+    config.analysis.tools.enabled &&
+    supportsToolCalling(resolvedLLMConfig.provider);
+
+  logger.debug(
+    `Analyzing ${included.length} files as one unit`,
+    included.map((f) => f.relPath),
+  );
+
+  const systemPrompt = buildSystemPrompt(config.rules, language?.id, {
+    toolsEnabled,
+    projectFacts: projectFactsFor(
+      included[0].filePath,
+      language?.id,
+      config,
+      rootDir,
+    ),
+    frameworks: detectUnitFrameworks(included, language?.id, rootDir),
+  });
+  const userPrompt = buildUnitUserPrompt(
+    included.map((f) => ({
+      filePath: f.relPath,
+      content: f.redacted?.text ?? f.content,
+      knownIssues: redactDiagnostics(f.knownIssues ?? [], redactor),
+      coverage: getCoverage(
+        f.filePath,
+        f.content,
+        language?.id,
+        config,
+        rootDir,
+      ),
+    })),
+    language?.id,
+  );
+
+  const llmStartTime = Date.now();
+
+  try {
+    const request: LLMRequestOptions = {
+      systemPrompt,
+      userPrompt,
+      config: resolvedLLMConfig,
+      rateLimitPerMinute: config.performance.rateLimitPerMinute,
+      rateLimitEnabled: config.performance.rateLimitEnabled,
+      requestId: `unit:${included.map((f) => f.filePath).join(",")}`,
+      filePath: included[0].filePath,
+      contextFiles: included.slice(1).map((f) => f.filePath),
+      auditLogPath: resolveAuditLogPath(config.audit),
+    };
+
+    const response = toolsEnabled
+      ? await sendLLMRequestWithTools(request, {
+          tools: CONTEXT_TOOLS,
+          execute: createContextToolExecutor({
+            rootDir,
+            config,
+            llm: resolvedLLMConfig,
+            redactor,
+          }),
+          maxCalls: config.analysis.tools.maxCalls,
+        })
+      : await sendLLMRequest(request);
+
+    const parseResult = parseResponse(response.content);
+    if (parseResult.parseError) {
+      logger.warn("Response parse issue:", parseResult.parseError);
+    }
+
+    // Split findings back to the files they were reported for
+    const byFile = new Map<string, Finding[]>(
+      included.map((f) => [f.relPath, []]),
+    );
+    const relPaths = included.map((f) => f.relPath);
+    for (const finding of parseResult.findings) {
+      const relPath = matchUnitFile(finding.file, relPaths);
+      if (!relPath) {
+        logger.warn(
+          `Dropping finding ${finding.id} for unknown file "${finding.file ?? ""}"`,
+        );
+        continue;
+      }
+      byFile.get(relPath)!.push({ ...finding, file: relPath });
+    }
+
+    for (const file of included) {
+      let fileFindings = dropDuplicateFindings(
+        byFile.get(file.relPath) ?? [],
+        file.knownIssues ?? [],
+      ).findings;
+
+      if (config.analysis.verify.enabled && fileFindings.length > 0) {
+        const verification = await verifyFindings({
+          filePath: file.filePath,
+          content: file.redacted?.text ?? file.content,
+          findings: fileFindings,
+          languageId: language?.id,
+          rootDir,
+          config,
+          llm: resolveVerifierConfig(config.llm, config.analysis.verify),
+        });
+        fileFindings = verification.findings;
+      }
+
+      const redacted = file.redacted;
+      results.set(file.filePath, {
+        findings: fitRanges(
+          redactor && redacted
+            ? fileFindings.map((f) => redactor.restoreFinding(f, redacted))
+            : fileFindings,
+          file.content,
+        ),
+        error: parseResult.parseError,
+        cached: false,
+        metrics: {
+          llmTimeMs: Date.now() - llmStartTime,
+          totalTimeMs: Date.now() - startTime,
+        },
+      });
+    }
+  } catch (error) {
+    const errorMessage = describeLLMError(error, resolvedLLMConfig.provider);
+    for (const file of included) {
+      results.set(file.filePath, {
+        findings: [],
+        error: errorMessage,
+        cached: false,
+        metrics: {
+          llmTimeMs: Date.now() - llmStartTime,
+          totalTimeMs: Date.now() - startTime,
+        },
+      });
+    }
+  }
+
+  return results;
+}
+
+/**
+ * Coverage of a file from the configured reports, if any.
+ * An unreadable report is logged and ignored.
+ */
+function getCoverage(
+  filePath: string,
+  content: string,
+  languageId: string | undefined,
+  config: AilintConfig,
+  rootDir: string,
+): CoverageSummary | undefined {
+  if (config.analysis.coverage.reports.length === 0) {
+    return undefined;
+  }
+
+  try {
+    const reports = loadCoverageReports(
+      config.analysis.coverage.reports,
+      rootDir,
+    );
+    const coverage = findFileCoverage(filePath, reports, rootDir);
+    return coverage
+      ? summarizeCoverage(content, languageId, coverage)
+      : undefined;
+  } catch (error) {
+    logger.warn(
+      `Cannot read coverage report: ${error instanceof Error ? error.message : error}`,
+    );
+    return undefined;
+  }
+}
+
+/**
+ * Toolchain facts from project files, plus the configured SQL dialect for
+ * SQL files.
+ */
+function projectFactsFor(
+  filePath: string,
+  languageId: string | undefined,
+  config: AilintConfig,
+  rootDir: string,
+): string[] {
+  const facts = detectProjectFacts(filePath, languageId, rootDir);
+  return languageId === "sql"
+    ? [...facts, ...sqlDialectFacts(config.analysis.sqlDialect)]
+    : facts;
+}
+
+/**
+ * Fit reported ranges to the analyzed text, so every output gets columns
+ * that exist on the line.
+ */
+function fitRanges(findings: Finding[], content: string): Finding[] {
+  const lines = splitLines(content);
+  return findings.map((finding) =>
+    finding.range
+      ? { ...finding, range: normalizeRange(finding.range, lines) }
+      : finding,
+  );
+}
+
+/**
+ * Linter messages can quote code, so they are scrubbed like the code itself.
+ */
+function redactDiagnostics(
+  diagnostics: ExternalDiagnostic[],
+  redactor: Redactor | null,
+): ExternalDiagnostic[] {
+  if (!redactor) {
+    return diagnostics;
+  }
+  return diagnostics.map((d) => ({
+    ...d,
+    message: redactor.redact(d.message).text,
+  }));
+}
+
+/**
+ * Frameworks used by any file of a unit.
+ */
+function detectUnitFrameworks(
+  files: UnitFile[],
+  languageId: string | undefined,
+  rootDir: string,
+): FrameworkConfig[] {
+  const byId = new Map<string, FrameworkConfig>();
+  for (const file of files) {
+    for (const framework of detectFrameworks(
+      file.filePath,
+      file.content,
+      languageId,
+      rootDir,
+    )) {
+      byId.set(framework.id, framework);
+    }
+  }
+  return Array.from(byId.values());
+}
+
+/**
+ * Turn a request failure into a user-facing error message.
+ * Returns undefined for requests cancelled by a newer one.
+ */
+function describeLLMError(
+  error: unknown,
+  provider: string,
+): string | undefined {
+  let errorMessage = "LLM request failed";
+
+  if (error instanceof LLMError) {
+    if (error.isAuthError()) {
+      errorMessage = `Invalid API key for ${provider}. Check your API key configuration.`;
+    } else if (error.isServerError()) {
+      errorMessage = "LLM service temporarily unavailable.";
+    } else {
+      errorMessage = error.message;
+    }
+  } else if (error instanceof Error) {
+    if (error.name === "TimeoutError") {
+      logger.debug("LLM request timed out");
+      return "Analysis timed out";
+    } else if (error instanceof RequestBudgetError) {
+      logger.debug(error.message);
+      return error.message;
+    } else if (error.message === "Request superseded by newer request") {
+      logger.debug("Request cancelled (superseded)");
+      return undefined;
+    } else {
+      errorMessage = error.message;
+    }
+  }
+
+  logger.error("LLM error:", errorMessage);
+  return errorMessage;
+}
@@ -0,0 +1,93 @@
+/**
+ * Split source files into function-sized chunks.
+ * Regex-based like the rest of language handling: a chunk starts at a
+ * top-level definition (plus its leading comments) and runs until the next.
+ */
+
+export interface CodeChunk {
+  startLine: number; // 1-indexed, inclusive
+  endLine: number; // 1-indexed, inclusive
+  text: string;
+}
+
+const MAX_CHUNK_LINES = 120;
+const FALLBACK_CHUNK_LINES = 60;
+
+/**
+ * Lines that start a new definition, per language.
+ */
+const DEFINITION_STARTS: Record<string, RegExp> = {
+  go: /^(?:func\s|type\s+\w+\s+(?:struct|interface)\b)/,
+  typescript:
+    /^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:async\s+)?(?:function\b|(?:abstract\s+)?class\b|interface\b|type\s+\w+|enum\b|const\s+\w+\s*(?::[^=]+)?=\s*(?:async\s*)?(?:\(|function\b))/,
+  python: /^(?: {0,4})(?:async\s+)?(?:def|class)\s/,
+  rust:
+    /^(?:pub(?:\([\w:]+\))?\s+)?(?:async\s+)?(?:fn|struct|enum|trait|impl)\b/,
+  java: /^(?: {0,4})(?:public|private|protected)\s[^=;]*[({]\s*$/,
+};
+
+const COMMENT_LINE = /^\s*(?:\/\/|#|\/\*|\*|"""|''')/;
+
+/**
+ * Chunk file content by function/type definitions.
+ * Files without recognizable definitions are split into fixed windows.
+ */
+export function chunkByFunction(
+  content: string,
+  languageId?: string,
+): CodeChunk[] {
+  const lines = content.split(/\r?\n/);
+  const startPattern = languageId ? DEFINITION_STARTS[languageId] : undefined;
+
+  const starts: number[] = [];
+  if (startPattern) {
+    for (let i = 0; i < lines.length; i++) {
+      if (!startPattern.test(lines[i])) continue;
+
+      // Attach leading comment lines (doc comments) to the definition
+      let start = i;
+      while (
+        start > 0 &&
+        COMMENT_LINE.test(lines[start - 1]) &&
+        !starts.includes(start - 1)
+      ) {
+        start--;
+      }
+      starts.push(start);
+    }
+  }
+
+  if (starts.length === 0) {
+    return splitWindows(lines, 0, lines.length, FALLBACK_CHUNK_LINES);
+  }
+
+  const chunks: CodeChunk[] = [];
+
+  // Preamble (package clause, imports) before the first definition
+  if (starts[0] > 0) {
+    chunks.push(...splitWindows(lines, 0, starts[0], MAX_CHUNK_LINES));
+  }
+
+  for (let i = 0; i < starts.length; i++) {
+    const end = i + 1 < starts.length ? starts[i + 1] : lines.length;
+    chunks.push(...splitWindows(lines, starts[i], end, MAX_CHUNK_LINES));
+  }
+
+  return chunks.filter((chunk) => chunk.text.trim().length > 0);
+}
+
+function splitWindows(
+  lines: string[],
+  from: number,
+  to: number,
+  size: number,
+): CodeChunk[] {
+  const chunks: CodeChunk[] = [];
+  for (let start = from; start < to; start += size) {
+    const end = Math.min(start + size, to);
+    const text = lines.slice(start, end).join("\n");
+    if (text.trim().length === 0) continue;
+    chunks.push({ startLine: start + 1, endLine: end, text });
+  }
+  return chunks;
+}
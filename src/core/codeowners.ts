@@ -0,0 +1,91 @@
+/**
+ * CODEOWNERS parsing, for routing findings to the teams that own the files.
+ */
+
+import { existsSync, readFileSync } from "node:fs";
+import { join, relative } from "node:path";
+import { matchGlob } from "../utils/glob-match.js";
+
+// Where GitHub and GitLab look for the file, in order
+const CODEOWNERS_PATHS = [
+  ".github/CODEOWNERS",
+  "CODEOWNERS",
+  "docs/CODEOWNERS",
+  ".gitlab/CODEOWNERS",
+];
+
+export interface CodeownersRule {
+  pattern: string;
+  owners: string[]; // Empty: the pattern explicitly has no owner
+}
+
+/**
+ * Parse CODEOWNERS lines into rules. GitLab section headers ("[Section]")
+ * are skipped; their rules are kept.
+ */
+export function parseCodeowners(text: string): CodeownersRule[] {
+  const rules: CodeownersRule[] = [];
+
+  for (const raw of text.split(/\r?\n/)) {
+    const line = raw.replace(/(^|\s)#.*$/, "").trim();
+    if (!line || /^\^?\[.*\]/.test(line)) continue;
+
+    // "\ " escapes a space inside a pattern
+    const [pattern, ...owners] = line
+      .replace(/\\ /g, "\u0000")
+      .split(/\s+/)
+      .map((part) => part.replace(/\u0000/g, " "));
+    rules.push({ pattern, owners });
+  }
+
+  return rules;
+}
+
+/**
+ * Path of the CODEOWNERS file for a repository, or null if there is none.
+ */
+export function findCodeowners(rootDir: string): string | null {
+  for (const candidate of CODEOWNERS_PATHS) {
+    const path = join(rootDir, candidate);
+    if (existsSync(path)) {
+      return path;
+    }
+  }
+  return null;
+}
+
+/**
+ * Load the repository's CODEOWNERS rules, or null if there is no file.
+ */
+export function loadCodeowners(rootDir: string): CodeownersRule[] | null {
+  const path = findCodeowners(rootDir);
+  return path ? parseCodeowners(readFileSync(path, "utf-8")) : null;
+}
+
+/**
+ * Owners of a file. As on GitHub, the last matching rule wins.
+ */
+export function ownersOf(
+  rules: CodeownersRule[],
+  filePath: string,
+  rootDir: string,
+): string[] {
+  const relativePath = relative(rootDir, filePath);
+
+  for (let i = rules.length - 1; i >= 0; i--) {
+    if (matchGlob(relativePath, rules[i].pattern)) {
+      return rules[i].owners;
+    }
+  }
+  return [];
+}
+
+/**
+ * Check whether a file is owned by any of the given owners. Owner names are
+ * compared case-insensitively, and the leading "@" is optional.
+ */
+export function isOwnedBy(owners: string[], wanted: string[]): boolean {
+  const normalize = (owner: string) => owner.replace(/^@/, "").toLowerCase();
+  const wantedSet = new Set(wanted.map(normalize));
+  return owners.some((owner) => wantedSet.has(normalize(owner)));
+}
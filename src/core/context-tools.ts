@@ -0,0 +1,454 @@
+/**
+ * Tools the model can call to gather more context during analysis.
+ *
+ * All tools run locally and are sandboxed to the workspace root. Results are
+ * subject to the egress policy and secret redaction like the analyzed file,
+ * and are capped by per-result and total token budgets.
+ */
+
+import { readFileSync, realpathSync, statSync } from "node:fs";
+import { isAbsolute, relative, resolve } from "node:path";
+import { glob } from "glob";
+import type { AilintConfig, ResolvedLLMConfig } from "../types/config.js";
+import type {
+  ToolCall,
+  ToolDefinition,
+  ToolExecutor,
+  ToolResult,
+} from "../llm/client.js";
+import { findMatchingGlob } from "../utils/glob-match.js";
+import { estimateTokens } from "../utils/tokens.js";
+import { checkEgressPolicy, toWorkspacePath } from "./egress-policy.js";
+import { getSupportedExtensions } from "./languages.js";
+import type { Redactor } from "./redactor.js";
+
+export const CONTEXT_TOOLS: ToolDefinition[] = [
+  {
+    name: "read_file",
+    description:
+      "Read lines from a file in the workspace. Use it to check callers, callees or types referenced by the code under review.",
+    parameters: {
+      type: "object",
+      properties: {
+        path: {
+          type: "string",
+          description: "File path relative to the workspace root",
+        },
+        startLine: {
+          type: "integer",
+          description: "First line to read (1-indexed, default 1)",
+        },
+        endLine: {
+          type: "integer",
+          description: "Last line to read (inclusive, default end of file)",
+        },
+      },
+      required: ["path"],
+    },
+  },
+  {
+    name: "find_definition",
+    description:
+      "Find where a function, method, type, class or variable is defined in the workspace.",
+    parameters: {
+      type: "object",
+      properties: {
+        symbol: {
+          type: "string",
+          description: "Identifier to look up, e.g. parseConfig",
+        },
+      },
+      required: ["symbol"],
+    },
+  },
+  {
+    name: "grep",
+    description:
+      "Search workspace files for a regular expression. Returns matching lines with file and line number.",
+    parameters: {
+      type: "object",
+      properties: {
+        pattern: {
+          type: "string",
+          description: "JavaScript regular expression",
+        },
+        glob: {
+          type: "string",
+          description: "Optional file glob to restrict the search, e.g. src/**/*.go",
+        },
+      },
+      required: ["pattern"],
+    },
+  },
+];
+
+const IGNORED_DIRS = ["**/node_modules/**", "**/dist/**", "**/.git/**"];
+// Files that hold credentials are never shared, whatever the config says
+const SECRET_FILES = [
+  ".env*",
+  "*.pem",
+  "*.key",
+  "id_{rsa,dsa,ecdsa,ed25519}*",
+  ".npmrc",
+  ".git",
+];
+const MAX_GREP_MATCHES = 50;
+const MAX_PATTERN_LENGTH = 200;
+const MAX_GREP_LINE_LENGTH = 1000; // Longer lines are only searched this far
+const MAX_GREP_BYTES = 4_000_000; // Stop searching after this much text
+const MAX_DEFINITIONS = 5;
+const DEFINITION_CONTEXT_LINES = 15;
+
+export interface ContextToolsOptions {
+  rootDir: string;
+  config: AilintConfig;
+  llm: ResolvedLLMConfig;
+  redactor: Redactor | null;
+}
+
+/**
+ * Create an executor for CONTEXT_TOOLS bound to a workspace.
+ */
+export function createContextToolExecutor(
+  options: ContextToolsOptions,
+): ToolExecutor {
+  const { rootDir, config, llm, redactor } = options;
+  const limits = config.analysis.tools;
+  let root: string;
+  try {
+    root = realpathSync(rootDir);
+  } catch {
+    root = resolve(rootDir);
+  }
+  let usedTokens = 0;
+  let fileList: Promise<string[]> | null = null;
+
+  // Source files in the workspace; symlinks leading out of it are dropped
+  const listFiles = (): Promise<string[]> => {
+    if (!fileList) {
+      const extensions = getSupportedExtensions().map((e) => e.slice(1));
+      fileList = glob(`**/*.{${extensions.join(",")}}`, {
+        cwd: root,
+        ignore: IGNORED_DIRS,
+        nodir: true,
+      }).then((found) => inWorkspace(root, found));
+    }
+    return fileList;
+  };
+
+  // Files the policy allows to be sent to this provider
+  const isAllowed = (relPath: string): boolean =>
+    !findMatchingGlob(relPath, SECRET_FILES) &&
+    checkEgressPolicy(relPath, llm, config.privacy, root) === null;
+
+  // Whole files are redacted, before they are numbered, searched or cut, so
+  // multi-line secrets and line-anchored patterns are still found
+  const readText = (relPath: string): string | null => {
+    const absPath = resolve(root, relPath);
+    try {
+      if (statSync(absPath).size > config.analysis.maxFileSize) return null;
+      const text = readFileSync(absPath, "utf-8");
+      return redactor ? redactor.redact(text).text : text;
+    } catch {
+      return null;
+    }
+  };
+
+  // Apply budgets to a tool result
+  const finish = (content: string, files: string[]): ToolResult => {
+    const remaining = limits.maxTotalTokens - usedTokens;
+    if (remaining <= 0) {
+      return {
+        content:
+          "Context budget exhausted. Answer with the information you have.",
+      };
+    }
+
+    const maxChars = Math.min(limits.maxResultTokens, remaining) * 4;
+    let text = content;
+    if (text.length > maxChars) {
+      text = `${text.slice(0, maxChars)}\n[truncated]`;
+    }
+
+    usedTokens += estimateTokens(text);
+    return {
+      content: text,
+      files: files.map((f) => resolve(root, f)),
+    };
+  };
+
+  const readFile = (input: Record<string, unknown>): ToolResult => {
+    const requested = typeof input["path"] === "string" ? input["path"] : "";
+    const relPath = resolveInWorkspace(root, requested);
+    if (!relPath) {
+      return { content: `Error: ${requested} is not a file in the workspace` };
+    }
+    if (!isAllowed(relPath)) {
+      return { content: `Error: ${relPath} may not be shared` };
+    }
+
+    const text = readText(relPath);
+    if (text === null) {
+      return { content: `Error: cannot read ${relPath}` };
+    }
+
+    const lines = text.split("\n");
+    const start = clampLine(input["startLine"], 1, lines.length);
+    const end = Math.max(
+      start,
+      clampLine(input["endLine"], lines.length, lines.length),
+    );
+    const numbered = lines
+      .slice(start - 1, end)
+      .map((line, i) => `${start + i}: ${line}`)
+      .join("\n");
+
+    return finish(`${relPath} (lines ${start}-${end}):\n${numbered}`, [
+      relPath,
+    ]);
+  };
+
+  const findDefinition = async (
+    input: Record<string, unknown>,
+  ): Promise<ToolResult> => {
+    const symbol = typeof input["symbol"] === "string" ? input["symbol"] : "";
+    if (!/^[A-Za-z_$][\w$]*$/.test(symbol)) {
+      return { content: `Error: invalid symbol "${symbol}"` };
+    }
+
+    const definition = definitionPattern(symbol);
+    const sections: string[] = [];
+    const files: string[] = [];
+
+    for (const relPath of await listFiles()) {
+      if (sections.length >= MAX_DEFINITIONS) break;
+      if (!isAllowed(relPath)) continue;
+
+      const text = readText(relPath);
+      if (!text || !text.includes(symbol)) continue;
+
+      const lines = text.split("\n");
+      for (let i = 0; i < lines.length; i++) {
+        if (!definition.test(lines[i])) continue;
+        const snippet = lines
+          .slice(i, i + DEFINITION_CONTEXT_LINES)
+          .map((line, j) => `${i + j + 1}: ${line}`)
+          .join("\n");
+        sections.push(`${relPath}:${i + 1}\n${snippet}`);
+        if (!files.includes(relPath)) files.push(relPath);
+        if (sections.length >= MAX_DEFINITIONS) break;
+      }
+    }
+
+    if (sections.length === 0) {
+      return { content: `No definition found for ${symbol}` };
+    }
+    return finish(sections.join("\n\n"), files);
+  };
+
+  const grep = async (input: Record<string, unknown>): Promise<ToolResult> => {
+    const source = typeof input["pattern"] === "string" ? input["pattern"] : "";
+    if (source.length > MAX_PATTERN_LENGTH) {
+      return {
+        content: `Error: pattern is longer than ${MAX_PATTERN_LENGTH} characters`,
+      };
+    }
+    // Patterns like (a+)+ can take exponential time on a single line
+    if (hasNestedQuantifier(source)) {
+      return {
+        content:
+          "Error: nested quantifiers and quantified alternations are not supported; use a simpler pattern",
+      };
+    }
+    let regex: RegExp;
+    try {
+      regex = new RegExp(source);
+    } catch (error) {
+      return {
+        content: `Error: invalid pattern: ${error instanceof Error ? error.message : source}`,
+      };
+    }
+
+    const fileGlob = typeof input["glob"] === "string" ? input["glob"] : null;
+    if (fileGlob && leavesWorkspace(fileGlob)) {
+      return {
+        content: `Error: glob ${fileGlob} must stay inside the workspace`,
+      };
+    }
+    const globbed = fileGlob
+      ? await glob(fileGlob, { cwd: root, ignore: IGNORED_DIRS, nodir: true })
+      : null;
+    const candidates = globbed ? inWorkspace(root, globbed) : await listFiles();
+
+    const matches: string[] = [];
+    const files: string[] = [];
+    let scanned = 0;
+
+    for (const relPath of candidates) {
+      if (matches.length >= MAX_GREP_MATCHES) break;
+      if (scanned >= MAX_GREP_BYTES) {
+        matches.push("[search stopped: too much text, narrow the glob]");
+        break;
+      }
+      if (!isAllowed(relPath)) continue;
+
+      const text = readText(relPath);
+      if (!text) continue;
+      scanned += text.length;
+
+      const lines = text.split("\n");
+      for (let i = 0; i < lines.length; i++) {
+        if (!regex.test(lines[i].slice(0, MAX_GREP_LINE_LENGTH))) continue;
+        matches.push(`${relPath}:${i + 1}: ${lines[i].trim()}`);
+        if (!files.includes(relPath)) files.push(relPath);
+        if (matches.length >= MAX_GREP_MATCHES) break;
+      }
+    }
+
+    if (matches.length === 0) {
+      return { content: `No matches for ${source}` };
+    }
+    return finish(matches.join("\n"), files);
+  };
+
+  return async (call: ToolCall): Promise<ToolResult> => {
+    switch (call.name) {
+      case "read_file":
+        return readFile(call.input);
+      case "find_definition":
+        return findDefinition(call.input);
+      case "grep":
+        return grep(call.input);
+      default:
+        return { content: `Error: unknown tool ${call.name}` };
+    }
+  };
+}
+
+/**
+ * Resolve a requested path to a workspace-relative path.
+ * Returns null for paths outside the root (including via symlinks) and for
+ * anything under .git.
+ */
+export function resolveInWorkspace(
+  root: string,
+  requested: string,
+): string | null {
+  if (!requested) return null;
+
+  let realPath: string;
+  try {
+    realPath = realpathSync(resolve(root, requested));
+    if (!statSync(realPath).isFile()) return null;
+  } catch {
+    return null;
+  }
+
+  const rel = relative(root, realPath);
+  if (!rel || rel.startsWith("..") || isAbsolute(rel)) {
+    return null;
+  }
+
+  const relPath = toWorkspacePath(rel, root);
+  if (relPath === ".git" || relPath.startsWith(".git/")) {
+    return null;
+  }
+  return relPath;
+}
+
+/**
+ * Whether a glob could match outside the root: absolute patterns, also as a
+ * brace alternative, and patterns going up with "..".
+ */
+function leavesWorkspace(pattern: string): boolean {
+  return (
+    isAbsolute(pattern) ||
+    pattern.includes("..") ||
+    /(?:^|[{,])\s*(?:[\\/]|[A-Za-z]:)/.test(pattern)
+  );
+}
+
+/**
+ * Resolve globbed paths with resolveInWorkspace, dropping those that lead
+ * out of the workspace.
+ */
+function inWorkspace(root: string, paths: string[]): string[] {
+  const resolved = new Set<string>();
+  for (const path of paths) {
+    const relPath = resolveInWorkspace(root, path);
+    if (relPath) resolved.add(relPath);
+  }
+  return Array.from(resolved);
+}
+
+/**
+ * Check a regular expression for a quantified group that itself contains a
+ * quantifier or an alternation, e.g. (a+)+ or (a|aa)*, the shapes that make
+ * backtracking exponential. Escapes and character classes are skipped.
+ */
+export function hasNestedQuantifier(source: string): boolean {
+  // For each open group: whether it contains a quantifier or alternation
+  const groups: boolean[] = [];
+  let inClass = false;
+
+  for (let i = 0; i < source.length; i++) {
+    const char = source[i];
+    if (char === "\\") {
+      i++;
+      continue;
+    }
+    if (inClass) {
+      inClass = char !== "]";
+      continue;
+    }
+
+    if (char === "[") {
+      inClass = true;
+    } else if (char === "(") {
+      groups.push(false);
+    } else if (char === ")") {
+      const repeats = groups.pop() ?? false;
+      const next = source[i + 1];
+      if (repeats && (next === "*" || next === "+" || next === "{")) {
+        return true;
+      }
+      if (repeats && groups.length > 0) {
+        groups[groups.length - 1] = true;
+      }
+    } else if (
+      (char === "*" || char === "+" || char === "{" || char === "|") &&
+      groups.length > 0
+    ) {
+      groups[groups.length - 1] = true;
+    }
+  }
+
+  return false;
+}
+
+/**
+ * Regex matching common definition forms across supported languages.
+ */
+function definitionPattern(symbol: string): RegExp {
+  const s = symbol.replace(/\$/g, "\\$");
+  return new RegExp(
+    [
+      // Go: func Name( / func (r *T) Name( / type Name
+      `^\\s*func\\s+(?:\\([^)]*\\)\\s*)?${s}\\s*[(\\[]`,
+      `^\\s*type\\s+${s}\\b`,
+      // TS/JS: function, class, interface, type, enum, const/let/var
+      `\\b(?:function\\*?|class|interface|enum)\\s+${s}\\b`,
+      `\\b(?:type)\\s+${s}\\s*[=<]`,
+      `\\b(?:const|let|var)\\s+${s}\\s*[:=]`,
+      // Python / Rust / Java-style methods
+      `^\\s*(?:async\\s+)?(?:def|fn|pub\\s+fn|struct|trait)\\s+${s}\\b`,
+      `^\\s*(?:public|private|protected|static|\\s)+[\\w<>\\[\\],\\s]+\\s+${s}\\s*\\(`,
+    ].join("|"),
+  );
+}
+
+function clampLine(value: unknown, fallback: number, max: number): number {
+  const n =
+    typeof value === "number" && Number.isFinite(value) ? value : fallback;
+  return Math.max(1, Math.min(Math.floor(n), Math.max(1, max)));
+}
@@ -0,0 +1,336 @@
+/**
+ * Test coverage from Go cover profiles, lcov and Cobertura reports, used to
+ * tell the model which functions are untested and to analyze untested code
+ * first.
+ */
+
+import { existsSync, readFileSync, statSync } from "node:fs";
+import { resolve } from "node:path";
+import { chunkByFunction } from "./chunker.js";
+import { toWorkspacePath } from "./egress-policy.js";
+
+export interface FunctionCoverage {
+  name: string;
+  startLine: number; // 1-indexed
+  hits: number;
+}
+
+export interface FileCoverage {
+  lines: Map<number, number>; // Instrumented line -> hit count
+  functions: FunctionCoverage[]; // Empty for formats without function data
+}
+
+// Coverage keyed by the path as written in the report
+export type CoverageReport = Map<string, FileCoverage>;
+
+export interface UntestedFunction {
+  name: string;
+  startLine: number;
+  endLine: number;
+}
+
+// Parsed reports keyed by path, invalidated when the report changes
+const reportCache = new Map<
+  string,
+  { mtimeMs: number; report: CoverageReport }
+>();
+
+/**
+ * Parse a coverage report, detecting its format from content.
+ */
+export function parseCoverageReport(text: string): CoverageReport {
+  const trimmed = text.trimStart();
+  if (trimmed.startsWith("mode:")) {
+    return parseGoCoverProfile(text);
+  }
+  if (trimmed.startsWith("<")) {
+    return parseCobertura(text);
+  }
+  if (/^(?:TN|SF):/m.test(text)) {
+    return parseLcov(text);
+  }
+  throw new Error(
+    "Unrecognized coverage format (expected Go cover profile, lcov or Cobertura XML)",
+  );
+}
+
+/**
+ * Go cover profile: "file.go:startLine.startCol,endLine.endCol stmts count".
+ */
+function parseGoCoverProfile(text: string): CoverageReport {
+  const report: CoverageReport = new Map();
+  const block = /^(.+\.go):(\d+)\.\d+,(\d+)\.\d+ \d+ (\d+)$/;
+
+  for (const line of text.split(/\r?\n/)) {
+    const match = block.exec(line.trim());
+    if (!match) continue;
+
+    const file = getOrCreate(report, match[1]);
+    const count = Number(match[4]);
+    for (let n = Number(match[2]); n <= Number(match[3]); n++) {
+      // Blocks can share a line; it is covered if any of them ran
+      file.lines.set(n, Math.max(file.lines.get(n) ?? 0, count));
+    }
+  }
+
+  return report;
+}
+
+function parseLcov(text: string): CoverageReport {
+  const report: CoverageReport = new Map();
+  let file: FileCoverage | null = null;
+  let functionLines = new Map<string, number>();
+
+  for (const raw of text.split(/\r?\n/)) {
+    const line = raw.trim();
+    const colon = line.indexOf(":");
+    const tag = colon >= 0 ? line.slice(0, colon) : line;
+    const value = line.slice(colon + 1);
+
+    if (tag === "SF") {
+      file = getOrCreate(report, value);
+      functionLines = new Map();
+    } else if (tag === "end_of_record") {
+      file = null;
+    } else if (file && tag === "DA") {
+      const [lineNumber, hits] = value.split(",");
+      file.lines.set(Number(lineNumber), Number(hits));
+    } else if (file && tag === "FN") {
+      const [lineNumber, ...name] = value.split(",");
+      functionLines.set(name.join(","), Number(lineNumber));
+    } else if (file && tag === "FNDA") {
+      const [hits, ...nameParts] = value.split(",");
+      const name = nameParts.join(",");
+      const startLine = functionLines.get(name);
+      if (startLine !== undefined) {
+        file.functions.push({ name, startLine, hits: Number(hits) });
+      }
+    }
+  }
+
+  return report;
+}
+
+/**
+ * Cobertura XML, read with regexes: class elements carry the file name,
+ * method elements their own lines.
+ */
+function parseCobertura(text: string): CoverageReport {
+  const report: CoverageReport = new Map();
+  const classPattern =
+    /<class\b[^>]*\bfilename="([^"]+)"[^>]*>([\s\S]*?)<\/class>/g;
+  const methodPattern =
+    /<method\b[^>]*\bname="([^"]+)"[^>]*>([\s\S]*?)<\/method>/g;
+  const linePattern = /<line\b[^>]*\bnumber="(\d+)"[^>]*\bhits="(\d+)"/g;
+
+  for (const classMatch of text.matchAll(classPattern)) {
+    const file = getOrCreate(report, classMatch[1]);
+    const body = classMatch[2];
+
+    for (const lineMatch of body.matchAll(linePattern)) {
+      const lineNumber = Number(lineMatch[1]);
+      const hits = Number(lineMatch[2]);
+      file.lines.set(
+        lineNumber,
+        Math.max(file.lines.get(lineNumber) ?? 0, hits),
+      );
+    }
+
+    for (const methodMatch of body.matchAll(methodPattern)) {
+      const lines = Array.from(methodMatch[2].matchAll(linePattern));
+      if (lines.length === 0) continue;
+      file.functions.push({
+        name: methodMatch[1],
+        startLine: Math.min(...lines.map((l) => Number(l[1]))),
+        hits: Math.max(...lines.map((l) => Number(l[2]))),
+      });
+    }
+  }
+
+  return report;
+}
+
+/**
+ * Load and cache the configured reports. Missing reports are skipped.
+ */
+export function loadCoverageReports(
+  reportPaths: string[],
+  rootDir: string,
+): CoverageReport[] {
+  const reports: CoverageReport[] = [];
+
+  for (const reportPath of reportPaths) {
+    const fullPath = resolve(rootDir, reportPath);
+    if (!existsSync(fullPath)) continue;
+
+    const mtimeMs = statSync(fullPath).mtimeMs;
+    const cached = reportCache.get(fullPath);
+    if (cached && cached.mtimeMs === mtimeMs) {
+      reports.push(cached.report);
+      continue;
+    }
+
+    const report = parseCoverageReport(readFileSync(fullPath, "utf-8"));
+    reportCache.set(fullPath, { mtimeMs, report });
+    reports.push(report);
+  }
+
+  return reports;
+}
+
+/**
+ * Find a file's coverage. Reports name files by absolute path, path relative
+ * to where tests ran, or Go import path, so a suffix match on the workspace
+ * path is accepted.
+ */
+export function findFileCoverage(
+  filePath: string,
+  reports: CoverageReport[],
+  rootDir: string,
+): FileCoverage | undefined {
+  const absolute = resolve(rootDir, filePath);
+  const relPath = toWorkspacePath(absolute, rootDir);
+
+  for (const report of reports) {
+    const direct = report.get(absolute) ?? report.get(relPath);
+    if (direct) {
+      return direct;
+    }
+
+    for (const [reportedPath, coverage] of report) {
+      const normalized = reportedPath.replace(/\\/g, "/");
+      if (normalized.endsWith(`/${relPath}`)) {
+        return coverage;
+      }
+    }
+  }
+
+  return undefined;
+}
+
+/**
+ * Functions with instrumented lines none of which ran. Uses the report's
+ * function data when present, otherwise function chunks of the source.
+ */
+export function findUntestedFunctions(
+  content: string,
+  languageId: string | undefined,
+  coverage: FileCoverage,
+): UntestedFunction[] {
+  const chunks = chunkByFunction(content, languageId);
+  const chunkAt = (line: number) =>
+    chunks.find((c) => c.startLine <= line && line <= c.endLine);
+
+  if (coverage.functions.length > 0) {
+    return coverage.functions
+      .filter((f) => f.hits === 0)
+      .map((f) => ({
+        name: f.name,
+        startLine: f.startLine,
+        endLine: chunkAt(f.startLine)?.endLine ?? f.startLine,
+      }));
+  }
+
+  const untested: UntestedFunction[] = [];
+  for (const chunk of chunks) {
+    let instrumented = 0;
+    let covered = 0;
+    for (let line = chunk.startLine; line <= chunk.endLine; line++) {
+      const hits = coverage.lines.get(line);
+      if (hits === undefined) continue;
+      instrumented++;
+      if (hits > 0) covered++;
+    }
+
+    if (instrumented > 0 && covered === 0) {
+      untested.push({
+        name: signatureOf(chunk.text),
+        startLine: chunk.startLine,
+        endLine: chunk.endLine,
+      });
+    }
+  }
+  return untested;
+}
+
+export interface CoverageSummary {
+  ratio: number; // Share of instrumented lines that ran, 0-1
+  instrumentedLines: number;
+  untested: UntestedFunction[];
+}
+
+/**
+ * Coverage facts about a file for the prompt.
+ */
+export function summarizeCoverage(
+  content: string,
+  languageId: string | undefined,
+  coverage: FileCoverage,
+): CoverageSummary {
+  return {
+    ratio: coverageRatio(coverage),
+    instrumentedLines: coverage.lines.size,
+    untested: findUntestedFunctions(content, languageId, coverage),
+  };
+}
+
+/**
+ * Share of instrumented lines that ran, 0-1.
+ */
+export function coverageRatio(coverage: FileCoverage): number {
+  if (coverage.lines.size === 0) {
+    return 1;
+  }
+  let covered = 0;
+  for (const hits of coverage.lines.values()) {
+    if (hits > 0) covered++;
+  }
+  return covered / coverage.lines.size;
+}
+
+/**
+ * Count instrumented lines that never ran, for prioritizing files.
+ */
+export function uncoveredLineCount(coverage: FileCoverage): number {
+  let uncovered = 0;
+  for (const hits of coverage.lines.values()) {
+    if (hits === 0) uncovered++;
+  }
+  return uncovered;
+}
+
+/**
+ * Order files so those with the most untested lines come first. Files the
+ * reports do not mention keep their order after the rest.
+ */
+export function orderByUncovered(
+  files: string[],
+  reports: CoverageReport[],
+  rootDir: string,
+): string[] {
+  const score = new Map<string, number>();
+  for (const file of files) {
+    const coverage = findFileCoverage(file, reports, rootDir);
+    score.set(file, coverage ? uncoveredLineCount(coverage) : -1);
+  }
+  return [...files].sort((a, b) => score.get(b)! - score.get(a)!);
+}
+
+function getOrCreate(report: CoverageReport, path: string): FileCoverage {
+  let file = report.get(path);
+  if (!file) {
+    file = { lines: new Map(), functions: [] };
+    report.set(path, file);
+  }
+  return file;
+}
+
+// First non-comment line of a chunk, without the opening brace
+function signatureOf(text: string): string {
+  const line =
+    text
+      .split("\n")
+      .map((l) => l.trim())
+      .find((l) => l && !/^(?:\/\/|#|\/\*|\*)/.test(l)) ?? "";
+  return line.replace(/\s*\{\s*$/, "").slice(0, 120);
+}
@@ -6,6 +6,16 @@ import {
 } from "vscode-languageserver";
 import type { Finding, FindingSeverity } from "../types/finding.js";
 import type { SeverityConfig } from "../types/config.js";
+import { encodeColumn, type PositionEncoding } from "./positions.js";
+
+/**
+ * Text of the open document and the encoding negotiated with the client,
+ * for converting finding columns into the client's code units.
+ */
+export interface DocumentPositions {
+  lines: string[];
+  encoding: PositionEncoding;
+}
 
 const SEVERITY_MAP: Record<FindingSeverity, DiagnosticSeverity> = {
   error: DiagnosticSeverity.Error,
@@ -44,17 +54,25 @@ function adjustSeverity(
 /**
  * Create a Range for a diagnostic.
  */
-function createRange(finding: Finding, lineCount: number): Range {
+function createRange(
+  finding: Finding,
+  lineCount: number,
+  document?: DocumentPositions,
+): Range {
   if (finding.range) {
+    const startLine = Math.min(finding.range.startLine, lineCount - 1);
+    const endLine = Math.min(finding.range.endLine, lineCount - 1);
+    const column = (line: number, character: number) =>
+      document
+        ? encodeColumn(document.lines[line] ?? "", character, document.encoding)
+        : character;
+
     return Range.create(
       Position.create(
-        Math.min(finding.range.startLine, lineCount - 1),
-        finding.range.startCharacter,
-      ),
-      Position.create(
-        Math.min(finding.range.endLine, lineCount - 1),
-        finding.range.endCharacter,
+        startLine,
+        column(startLine, finding.range.startCharacter),
       ),
+      Position.create(endLine, column(endLine, finding.range.endCharacter)),
     );
   }
 
@@ -69,9 +87,10 @@ export function findingToDiagnostic(
   finding: Finding,
   severityConfig: SeverityConfig,
   lineCount: number = 1,
+  document?: DocumentPositions,
 ): Diagnostic {
   const severity = adjustSeverity(finding, severityConfig);
-  const range = createRange(finding, lineCount);
+  const range = createRange(finding, lineCount, document);
 
   // Build message with suggestion
   let message = finding.message;
@@ -100,9 +119,10 @@ export function findingsToDiagnostics(
   findings: Finding[],
   severityConfig: SeverityConfig,
   lineCount: number = 1,
+  document?: DocumentPositions,
 ): Diagnostic[] {
   return findings.map((finding) =>
-    findingToDiagnostic(finding, severityConfig, lineCount),
+    findingToDiagnostic(finding, severityConfig, lineCount, document),
   );
 }
 
@@ -132,6 +152,8 @@ export function categoryToString(category: string): string {
     spaghetti: "Spaghetti Code",
     naming: "Naming Issue",
     safety: "Type Safety",
+    untested: "Untested Complexity",
+    documentation: "Documentation",
   };
   return map[category] ?? category;
 }
@@ -0,0 +1,88 @@
+/**
+ * Data egress policy: decides whether a file may be sent to a provider.
+ */
+
+import { relative, isAbsolute } from "node:path";
+import type { PrivacyConfig, ResolvedLLMConfig } from "../types/config.js";
+import { findMatchingGlob } from "../utils/glob-match.js";
+
+const LOCAL_HOSTNAMES = new Set(["localhost", "0.0.0.0", "[::1]", "::1"]);
+
+/**
+ * Check whether an LLM endpoint runs on this machine.
+ * Locality is decided by the endpoint host, not the provider name, so an
+ * Ollama instance on a remote server counts as remote.
+ */
+export function isLocalProvider(llm: ResolvedLLMConfig): boolean {
+  let hostname: string;
+  try {
+    hostname = new URL(llm.baseUrl).hostname.toLowerCase();
+  } catch {
+    return false;
+  }
+
+  return (
+    LOCAL_HOSTNAMES.has(hostname) ||
+    hostname.endsWith(".localhost") ||
+    /^127\.\d+\.\d+\.\d+$/.test(hostname)
+  );
+}
+
+/**
+ * Check whether the privacy config allows a remote provider at all.
+ */
+export function isRemoteProviderAllowed(
+  llm: ResolvedLLMConfig,
+  privacy: PrivacyConfig,
+): boolean {
+  const allow = privacy.allowRemoteProviders;
+  if (Array.isArray(allow)) {
+    return allow.includes(llm.provider);
+  }
+  return allow;
+}
+
+/**
+ * Get a file path relative to the workspace root, using forward slashes.
+ */
+export function toWorkspacePath(filePath: string, rootDir: string): string {
+  const rel = isAbsolute(filePath) ? relative(rootDir, filePath) : filePath;
+  return rel.split("\\").join("/");
+}
+
+/**
+ * Check whether content from filePath may be sent to the given provider.
+ * Returns a human-readable reason if the policy forbids it, null otherwise.
+ */
+export function checkEgressPolicy(
+  filePath: string,
+  llm: ResolvedLLMConfig,
+  privacy: PrivacyConfig,
+  rootDir: string,
+): string | null {
+  if (isLocalProvider(llm)) {
+    return null;
+  }
+
+  const endpoint = describeEndpoint(llm);
+  const relPath = toWorkspacePath(filePath, rootDir);
+
+  if (!isRemoteProviderAllowed(llm, privacy)) {
+    return `Refusing to send ${relPath} to ${endpoint}: remote provider not allowed by privacy.allowRemoteProviders`;
+  }
+
+  const pattern = findMatchingGlob(relPath, privacy.localOnlyPaths);
+  if (pattern) {
+    return `Refusing to send ${relPath} to ${endpoint}: path matches privacy.localOnlyPaths pattern "${pattern}" and may only be analyzed by a local provider`;
+  }
+
+  return null;
+}
+
+function describeEndpoint(llm: ResolvedLLMConfig): string {
+  try {
+    return `${llm.provider} (${new URL(llm.baseUrl).host})`;
+  } catch {
+    return llm.provider;
+  }
+}
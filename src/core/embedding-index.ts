@@ -0,0 +1,398 @@
+/**
+ * On-disk embedding index of function-sized chunks, used to retrieve similar
+ * code from the rest of the repository into the analysis prompt.
+ *
+ * The index stores only positions, hashes and vectors. Snippet text is read
+ * from disk at retrieval time, and chunks whose text changed since indexing
+ * are skipped.
+ */
+
+import {
+  existsSync,
+  mkdirSync,
+  readFileSync,
+  statSync,
+  writeFileSync,
+} from "node:fs";
+import { dirname, resolve } from "node:path";
+import { createHash } from "node:crypto";
+import type { AilintConfig, ResolvedLLMConfig } from "../types/config.js";
+import {
+  requestEmbeddings,
+  resolveEmbeddingConfig,
+} from "../llm/embeddings.js";
+import { resolveAuditLogPath } from "../llm/audit-log.js";
+import { estimateTokens } from "../utils/tokens.js";
+import { logger } from "../utils/logger.js";
+import { chunkByFunction } from "./chunker.js";
+import { checkEgressPolicy, toWorkspacePath } from "./egress-policy.js";
+import { getLanguageForFile } from "./languages.js";
+import type { Redactor } from "./redactor.js";
+import { detectSkipReason } from "./skip-detect.js";
+
+const INDEX_VERSION = 1;
+const MAX_QUERY_CHUNKS = 8;
+
+export interface IndexedChunk {
+  file: string; // Workspace-relative path
+  startLine: number;
+  endLine: number;
+  hash: string;
+  vector: number[];
+}
+
+export interface EmbeddingIndex {
+  version: number;
+  model: string;
+  createdAt: string;
+  chunks: IndexedChunk[];
+}
+
+export interface RelatedSnippet {
+  file: string;
+  startLine: number;
+  endLine: number;
+  text: string;
+  score: number;
+}
+
+export interface BuildIndexOptions {
+  files: string[];
+  rootDir: string;
+  config: AilintConfig;
+  redactor: Redactor | null;
+  previous?: EmbeddingIndex | null; // Reuse vectors of unchanged chunks
+  onFile?: (filePath: string, chunks: number) => void;
+}
+
+export interface BuildIndexResult {
+  index: EmbeddingIndex;
+  embedded: number;
+  reused: number;
+  skipped: string[]; // Files excluded by the egress policy
+}
+
+/**
+ * Chunk files and embed every chunk that is not already in the previous index.
+ */
+export async function buildEmbeddingIndex(
+  options: BuildIndexOptions,
+): Promise<BuildIndexResult> {
+  const { files, rootDir, config, redactor, previous } = options;
+  const retrieval = config.analysis.retrieval;
+  const embedder = resolveEmbeddingConfig(retrieval, config);
+  const auditLogPath = resolveAuditLogPath(config.audit);
+
+  const reusable = new Map<string, number[]>();
+  if (previous && previous.model === retrieval.model) {
+    for (const chunk of previous.chunks) {
+      reusable.set(chunkKey(chunk.file, chunk.hash), chunk.vector);
+    }
+  }
+
+  const chunks: IndexedChunk[] = [];
+  const skipped: string[] = [];
+  let embedded = 0;
+  let reused = 0;
+
+  for (const filePath of files) {
+    const relPath = toWorkspacePath(filePath, rootDir);
+    const violation = checkEgressPolicy(
+      relPath,
+      embedder,
+      config.privacy,
+      rootDir,
+    );
+    if (violation) {
+      logger.debug(violation);
+      skipped.push(relPath);
+      continue;
+    }
+
+    let content: string;
+    try {
+      if (statSync(filePath).size > config.analysis.maxFileSize) continue;
+      content = readFileSync(filePath, "utf-8");
+    } catch (error) {
+      logger.warn(`Cannot read ${relPath}:`, error);
+      continue;
+    }
+    if (
+      config.analysis.skipGenerated &&
+      detectSkipReason(relPath, content, rootDir)
+    ) {
+      continue;
+    }
+
+    const language = getLanguageForFile(filePath);
+    const fileChunks = chunkByFunction(content, language?.id);
+    const pending: IndexedChunk[] = [];
+    const pendingTexts: string[] = [];
+
+    for (const chunk of fileChunks) {
+      const hash = hashText(chunk.text);
+      const entry: IndexedChunk = {
+        file: relPath,
+        startLine: chunk.startLine,
+        endLine: chunk.endLine,
+        hash,
+        vector: reusable.get(chunkKey(relPath, hash)) ?? [],
+      };
+      chunks.push(entry);
+
+      if (entry.vector.length > 0) {
+        reused++;
+      } else {
+        pending.push(entry);
+        pendingTexts.push(
+          redactor ? redactor.redact(chunk.text).text : chunk.text,
+        );
+      }
+    }
+
+    if (pending.length > 0) {
+      const vectors = await requestEmbeddings(pendingTexts, {
+        config: embedder,
+        filePath,
+        auditLogPath,
+      });
+      pending.forEach((entry, i) => {
+        entry.vector = vectors[i];
+      });
+      embedded += pending.length;
+    }
+
+    options.onFile?.(relPath, fileChunks.length);
+  }
+
+  return {
+    index: {
+      version: INDEX_VERSION,
+      model: retrieval.model,
+      createdAt: new Date().toISOString(),
+      chunks,
+    },
+    embedded,
+    reused,
+    skipped,
+  };
+}
+
+/**
+ * Load an index from disk. Returns null if it is missing or unreadable.
+ */
+export function loadEmbeddingIndex(indexPath: string): EmbeddingIndex | null {
+  if (!existsSync(indexPath)) {
+    return null;
+  }
+
+  try {
+    const index = JSON.parse(
+      readFileSync(indexPath, "utf-8"),
+    ) as EmbeddingIndex;
+    if (index.version !== INDEX_VERSION || !Array.isArray(index.chunks)) {
+      logger.warn(`Ignoring incompatible embedding index ${indexPath}`);
+      return null;
+    }
+    return index;
+  } catch (error) {
+    logger.warn(`Failed to read embedding index ${indexPath}:`, error);
+    return null;
+  }
+}
+
+export function saveEmbeddingIndex(
+  indexPath: string,
+  index: EmbeddingIndex,
+): void {
+  mkdirSync(dirname(indexPath), { recursive: true });
+  writeFileSync(indexPath, JSON.stringify(index), "utf-8");
+}
+
+// Parsed indexes keyed by path, invalidated when the file changes
+const indexCache = new Map<
+  string,
+  { mtimeMs: number; index: EmbeddingIndex | null }
+>();
+
+function getCachedIndex(indexPath: string): EmbeddingIndex | null {
+  let mtimeMs: number;
+  try {
+    mtimeMs = statSync(indexPath).mtimeMs;
+  } catch {
+    return null;
+  }
+
+  const cached = indexCache.get(indexPath);
+  if (cached && cached.mtimeMs === mtimeMs) {
+    return cached.index;
+  }
+
+  const index = loadEmbeddingIndex(indexPath);
+  indexCache.set(indexPath, { mtimeMs, index });
+  return index;
+}
+
+export interface RetrieveOptions {
+  filePath: string;
+  content: string;
+  languageId?: string;
+  rootDir: string;
+  config: AilintConfig;
+  llm: ResolvedLLMConfig; // Provider the snippets will be sent to
+  redactor: Redactor | null;
+}
+
+/**
+ * Find code elsewhere in the repository that is most similar to the file
+ * under analysis. Snippets the egress policy does not allow to be sent to the
+ * analysis provider are left out, and the rest are redacted.
+ */
+export async function retrieveRelatedCode(
+  options: RetrieveOptions,
+): Promise<RelatedSnippet[]> {
+  const { filePath, content, languageId, rootDir, config, llm, redactor } =
+    options;
+  const retrieval = config.analysis.retrieval;
+
+  const indexPath = resolve(rootDir, retrieval.indexPath);
+  const index = getCachedIndex(indexPath);
+  if (!index) {
+    logger.debug(`No embedding index at ${indexPath}, run "lintai index"`);
+    return [];
+  }
+  if (index.model !== retrieval.model) {
+    logger.warn(
+      `Embedding index was built with ${index.model}, not ${retrieval.model}. Run "lintai index" to rebuild it.`,
+    );
+    return [];
+  }
+
+  const embedder = resolveEmbeddingConfig(retrieval, config);
+  const violation = checkEgressPolicy(
+    filePath,
+    embedder,
+    config.privacy,
+    rootDir,
+  );
+  if (violation) {
+    logger.debug(violation);
+    return [];
+  }
+
+  const queryTexts = chunkByFunction(content, languageId)
+    .slice(0, MAX_QUERY_CHUNKS)
+    .map((chunk) => (redactor ? redactor.redact(chunk.text).text : chunk.text));
+  if (queryTexts.length === 0) {
+    return [];
+  }
+
+  const queries = await requestEmbeddings(queryTexts, {
+    config: embedder,
+    filePath,
+    auditLogPath: resolveAuditLogPath(config.audit),
+  });
+
+  const relPath = toWorkspacePath(filePath, rootDir);
+  const candidates = rankChunks(index.chunks, queries, relPath).filter(
+    (c) => c.score >= retrieval.minScore,
+  );
+
+  const snippets: RelatedSnippet[] = [];
+  let usedTokens = 0;
+
+  for (const { chunk, score } of candidates) {
+    if (snippets.length >= retrieval.topK) break;
+    if (checkEgressPolicy(chunk.file, llm, config.privacy, rootDir)) continue;
+
+    const text = readChunkText(resolve(rootDir, chunk.file), chunk);
+    if (text === null) continue;
+
+    const redactedText = redactor ? redactor.redact(text).text : text;
+    const tokens = estimateTokens(redactedText);
+    if (usedTokens + tokens > retrieval.maxTokens) continue;
+
+    usedTokens += tokens;
+    snippets.push({
+      file: chunk.file,
+      startLine: chunk.startLine,
+      endLine: chunk.endLine,
+      text: redactedText,
+      score,
+    });
+  }
+
+  logger.debug(
+    `Retrieved ${snippets.length} related snippet(s) for ${relPath}`,
+  );
+  return snippets;
+}
+
+/**
+ * Score indexed chunks by their best cosine similarity to any query vector,
+ * excluding chunks from the file under analysis. Highest score first.
+ */
+export function rankChunks(
+  chunks: IndexedChunk[],
+  queries: number[][],
+  excludeFile: string,
+): Array<{ chunk: IndexedChunk; score: number }> {
+  const ranked: Array<{ chunk: IndexedChunk; score: number }> = [];
+
+  for (const chunk of chunks) {
+    if (chunk.file === excludeFile || chunk.vector.length === 0) continue;
+    let score = -1;
+    for (const query of queries) {
+      score = Math.max(score, cosineSimilarity(query, chunk.vector));
+    }
+    ranked.push({ chunk, score });
+  }
+
+  return ranked.sort((a, b) => b.score - a.score);
+}
+
+export function cosineSimilarity(a: number[], b: number[]): number {
+  if (a.length !== b.length || a.length === 0) {
+    return 0;
+  }
+
+  let dot = 0;
+  let normA = 0;
+  let normB = 0;
+  for (let i = 0; i < a.length; i++) {
+    dot += a[i] * b[i];
+    normA += a[i] * a[i];
+    normB += b[i] * b[i];
+  }
+
+  if (normA === 0 || normB === 0) {
+    return 0;
+  }
+  return dot / (Math.sqrt(normA) * Math.sqrt(normB));
+}
+
+/**
+ * Read a chunk's current text, or null if the file changed since indexing.
+ */
+function readChunkText(absPath: string, chunk: IndexedChunk): string | null {
+  let content: string;
+  try {
+    content = readFileSync(absPath, "utf-8");
+  } catch {
+    return null;
+  }
+
+  const text = content
+    .split(/\r?\n/)
+    .slice(chunk.startLine - 1, chunk.endLine)
+    .join("\n");
+  return hashText(text) === chunk.hash ? text : null;
+}
+
+function hashText(text: string): string {
+  return createHash("sha256").update(text).digest("hex").slice(0, 16);
+}
+
+function chunkKey(file: string, hash: string): string {
+  return `${file}\0${hash}`;
+}
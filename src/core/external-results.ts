@@ -0,0 +1,435 @@
+/**
+ * Results from other linters (SARIF, eslint JSON, golangci-lint JSON, tsc
+ * output), used as "already reported" context and to drop duplicate findings.
+ */
+
+import { readFileSync } from "node:fs";
+import { isAbsolute, relative, resolve } from "node:path";
+import { fileURLToPath } from "node:url";
+import type { Finding } from "../types/finding.js";
+
+export interface ExternalDiagnostic {
+  file: string; // Absolute path
+  line: number; // 1-indexed
+  endLine?: number;
+  column?: number;
+  rule?: string;
+  message: string;
+  source: string; // Tool that reported it, e.g. "golangci-lint"
+  level?: string; // Tool-specific severity, e.g. "error", "warning", "note"
+}
+
+/**
+ * Load diagnostics from a results file, detecting its format from content.
+ * Relative paths in the results are resolved against baseDir.
+ */
+export function loadExternalResults(
+  resultsPath: string,
+  baseDir: string,
+): ExternalDiagnostic[] {
+  const text = readFileSync(resultsPath, "utf-8");
+  return parseExternalResults(text, baseDir);
+}
+
+/**
+ * Parse results text in any supported format.
+ */
+export function parseExternalResults(
+  text: string,
+  baseDir: string,
+): ExternalDiagnostic[] {
+  const trimmed = text.trim();
+  if (trimmed.startsWith("{") || trimmed.startsWith("[")) {
+    let data: unknown;
+    try {
+      data = JSON.parse(trimmed);
+    } catch {
+      throw new Error("Results file is not valid JSON");
+    }
+
+    if (isObject(data) && Array.isArray(data["runs"])) {
+      return parseSarif(data, baseDir);
+    }
+    if (isObject(data) && "Issues" in data) {
+      return parseGolangciLint(data, baseDir);
+    }
+    if (
+      Array.isArray(data) &&
+      data.every((r) => isObject(r) && "messages" in r)
+    ) {
+      return parseEslint(data, baseDir);
+    }
+    throw new Error(
+      "Unrecognized results format (expected SARIF, eslint JSON or golangci-lint JSON)",
+    );
+  }
+
+  return parseTscOutput(text, baseDir);
+}
+
+function parseSarif(
+  sarif: Record<string, unknown>,
+  baseDir: string,
+): ExternalDiagnostic[] {
+  const diagnostics: ExternalDiagnostic[] = [];
+
+  for (const run of sarif["runs"] as unknown[]) {
+    if (!isObject(run)) continue;
+    const driver = getPath(run, ["tool", "driver"]);
+    const source =
+      isObject(driver) && typeof driver["name"] === "string"
+        ? driver["name"]
+        : "sarif";
+
+    for (const result of (run["results"] as unknown[]) ?? []) {
+      if (!isObject(result)) continue;
+      const location = (result["locations"] as unknown[] | undefined)?.[0];
+      const physical = isObject(location)
+        ? location["physicalLocation"]
+        : undefined;
+      if (!isObject(physical)) continue;
+
+      const uri = getPath(physical, ["artifactLocation", "uri"]);
+      const region = isObject(physical["region"]) ? physical["region"] : {};
+      if (typeof uri !== "string") continue;
+
+      diagnostics.push({
+        file: resolveResultPath(uri, baseDir),
+        line: numberOr(region["startLine"], 1),
+        endLine: numberOr(region["endLine"], undefined),
+        column: numberOr(region["startColumn"], undefined),
+        rule:
+          typeof result["ruleId"] === "string" ? result["ruleId"] : undefined,
+        message: String(getPath(result, ["message", "text"]) ?? ""),
+        source,
+        level:
+          typeof result["level"] === "string" ? result["level"] : undefined,
+      });
+    }
+  }
+
+  return diagnostics;
+}
+
+function parseEslint(
+  results: unknown[],
+  baseDir: string,
+): ExternalDiagnostic[] {
+  const diagnostics: ExternalDiagnostic[] = [];
+
+  for (const result of results) {
+    if (!isObject(result) || typeof result["filePath"] !== "string") continue;
+    const file = resolveResultPath(result["filePath"], baseDir);
+
+    for (const message of result["messages"] as unknown[]) {
+      if (!isObject(message)) continue;
+      diagnostics.push({
+        file,
+        line: numberOr(message["line"], 1),
+        endLine: numberOr(message["endLine"], undefined),
+        column: numberOr(message["column"], undefined),
+        rule:
+          typeof message["ruleId"] === "string" ? message["ruleId"] : undefined,
+        message: String(message["message"] ?? ""),
+        source: "eslint",
+        level: message["severity"] === 2 ? "error" : "warning",
+      });
+    }
+  }
+
+  return diagnostics;
+}
+
+function parseGolangciLint(
+  data: Record<string, unknown>,
+  baseDir: string,
+): ExternalDiagnostic[] {
+  const diagnostics: ExternalDiagnostic[] = [];
+
+  for (const issue of (data["Issues"] as unknown[] | null) ?? []) {
+    if (!isObject(issue) || !isObject(issue["Pos"])) continue;
+    const pos = issue["Pos"];
+    if (typeof pos["Filename"] !== "string") continue;
+
+    diagnostics.push({
+      file: resolveResultPath(pos["Filename"], baseDir),
+      line: numberOr(pos["Line"], 1),
+      column: numberOr(pos["Column"], undefined),
+      rule:
+        typeof issue["FromLinter"] === "string"
+          ? issue["FromLinter"]
+          : undefined,
+      message: String(issue["Text"] ?? ""),
+      source: "golangci-lint",
+      level:
+        typeof issue["Severity"] === "string" ? issue["Severity"] : undefined,
+    });
+  }
+
+  return diagnostics;
+}
+
+/**
+ * Parse tsc output in both the default and --pretty false formats:
+ *   src/a.ts(12,5): error TS2322: message
+ *   src/a.ts:12:5 - error TS2322: message
+ */
+function parseTscOutput(text: string, baseDir: string): ExternalDiagnostic[] {
+  const diagnostics: ExternalDiagnostic[] = [];
+  const pattern =
+    /^(.+?)(?:\((\d+),(\d+)\)|:(\d+):(\d+))\s*:?\s*-?\s*(error|warning)\s+(TS\d+):\s*(.*)$/;
+
+  for (const line of text.split(/\r?\n/)) {
+    const match = pattern.exec(line.trim());
+    if (!match) continue;
+
+    diagnostics.push({
+      file: resolveResultPath(match[1], baseDir),
+      line: Number(match[2] ?? match[4]),
+      column: Number(match[3] ?? match[5]),
+      rule: match[7],
+      message: match[8],
+      source: "tsc",
+      level: match[6],
+    });
+  }
+
+  return diagnostics;
+}
+
+/**
+ * Group diagnostics by absolute file path.
+ */
+export function groupDiagnosticsByFile(
+  diagnostics: ExternalDiagnostic[],
+): Map<string, ExternalDiagnostic[]> {
+  const byFile = new Map<string, ExternalDiagnostic[]>();
+  for (const diagnostic of diagnostics) {
+    const list = byFile.get(diagnostic.file) ?? [];
+    list.push(diagnostic);
+    byFile.set(diagnostic.file, list);
+  }
+  return byFile;
+}
+
+// Phrases a finding uses for issues that linters report under these rules.
+// They must be specific to the rule: a finding that merely mentions "error"
+// next to an errcheck diagnostic, or "any" next to no-explicit-any, is about
+// something else.
+const UNUSED_VARIABLE_PHRASES = [
+  "unused variable",
+  "unused import",
+  "unused parameter",
+  "declared but never used",
+  "assigned but never used",
+  "variable is never used",
+];
+
+const RULE_KEYWORDS: Record<string, string[]> = {
+  errcheck: [
+    "unchecked error",
+    "error is ignored",
+    "ignored error",
+    "error not checked",
+    "error is not checked",
+    "error return value",
+    "unhandled error",
+  ],
+  ineffassign: [
+    "ineffectual assignment",
+    "ineffective assignment",
+    "assigned but never used",
+    "value is never used",
+    "overwritten before it is used",
+    "overwritten before use",
+  ],
+  unused: [
+    "unused function",
+    "unused method",
+    "unused type",
+    "unused field",
+    "unused constant",
+    "unused variable",
+    "dead code",
+    "is never used",
+    "never called",
+  ],
+  gosec: [
+    "sql injection",
+    "command injection",
+    "path traversal",
+    "hardcoded credential",
+    "hardcoded password",
+    "weak random",
+    "insecure random",
+    "weak hash",
+    "weak cipher",
+    "insecure tls",
+    "tls verification",
+    "insecureskipverify",
+  ],
+  "no-explicit-any": ["explicit any", "type any", "any type", "as any"],
+  "@typescript-eslint/no-explicit-any": [
+    "explicit any",
+    "type any",
+    "any type",
+    "as any",
+  ],
+  "@typescript-eslint/no-unused-vars": UNUSED_VARIABLE_PHRASES,
+  "no-unused-vars": UNUSED_VARIABLE_PHRASES,
+  "@typescript-eslint/no-floating-promises": [
+    "floating promise",
+    "unhandled promise",
+    "promise is not awaited",
+    "promise not awaited",
+    "missing await",
+  ],
+  "no-empty": [
+    "empty catch",
+    "empty block",
+    "empty function",
+    "swallowed error",
+    "swallows the error",
+    "swallows error",
+  ],
+};
+
+const LINE_TOLERANCE = 1;
+const MIN_SIMILARITY = 0.3;
+
+/**
+ * Check whether a finding reports the same issue as an external diagnostic:
+ * overlapping lines and a similar description.
+ */
+export function isDuplicateOf(
+  finding: Finding,
+  diagnostic: ExternalDiagnostic,
+): boolean {
+  if (!finding.range) {
+    return false;
+  }
+
+  // Diagnostics count lines from 1, finding ranges from 0
+  const diagStart = diagnostic.line;
+  const diagEnd = diagnostic.endLine ?? diagnostic.line;
+  if (
+    finding.range.startLine + 1 > diagEnd + LINE_TOLERANCE ||
+    finding.range.endLine + 1 < diagStart - LINE_TOLERANCE
+  ) {
+    return false;
+  }
+
+  const findingText = `${finding.title} ${finding.message}`;
+  // Rules with known phrasing are matched on it alone; word overlap with
+  // short messages like "Unexpected any" is mostly chance
+  const keywords = diagnostic.rule ? RULE_KEYWORDS[diagnostic.rule] : undefined;
+  if (keywords) {
+    return keywords.some((k) => containsPhrase(findingText, k));
+  }
+
+  const findingWords = significantWords(findingText);
+
+  const diagWords = significantWords(
+    `${diagnostic.rule ?? ""} ${diagnostic.message}`,
+  );
+  let shared = 0;
+  for (const word of diagWords) {
+    if (findingWords.has(word)) shared++;
+  }
+  const smaller = Math.min(diagWords.size, findingWords.size);
+  return smaller > 0 && shared / smaller >= MIN_SIMILARITY;
+}
+
+/**
+ * Drop findings that duplicate diagnostics already reported by other tools.
+ */
+export function dropDuplicateFindings(
+  findings: Finding[],
+  diagnostics: ExternalDiagnostic[],
+): { findings: Finding[]; dropped: number } {
+  if (diagnostics.length === 0) {
+    return { findings, dropped: 0 };
+  }
+
+  const kept = findings.filter(
+    (f) => !diagnostics.some((d) => isDuplicateOf(f, d)),
+  );
+  return { findings: kept, dropped: findings.length - kept.length };
+}
+
+function significantWords(text: string): Set<string> {
+  const words = new Set<string>();
+  for (const raw of text.toLowerCase().split(/[^a-z0-9]+/)) {
+    if (raw.length < 3 || STOP_WORDS.has(raw)) continue;
+    words.add(stem(raw));
+  }
+  return words;
+}
+
+/**
+ * Check whether text contains the words of a phrase in order, ignoring
+ * case, punctuation and word endings.
+ */
+function containsPhrase(text: string, phrase: string): boolean {
+  const words = (s: string) =>
+    s
+      .toLowerCase()
+      .split(/[^a-z0-9]+/)
+      .filter(Boolean)
+      .map(stem);
+  return ` ${words(text).join(" ")} `.includes(` ${words(phrase).join(" ")} `);
+}
+
+// Crude stemming so "checked"/"checking"/"checks" match
+function stem(word: string): string {
+  return word.replace(/(?:ing|ed|es|s)$/, "");
+}
+
+const STOP_WORDS = new Set([
+  "the",
+  "and",
+  "for",
+  "not",
+  "this",
+  "that",
+  "with",
+  "from",
+  "should",
+  "value",
+  "function",
+  "call",
+]);
+
+function resolveResultPath(path: string, baseDir: string): string {
+  if (path.startsWith("file://")) {
+    return fileURLToPath(path);
+  }
+  return isAbsolute(path) ? path : resolve(baseDir, path);
+}
+
+/**
+ * Path of a diagnostic relative to the workspace root, for display.
+ */
+export function diagnosticPath(
+  diagnostic: ExternalDiagnostic,
+  rootDir: string,
+): string {
+  return relative(rootDir, diagnostic.file).split("\\").join("/");
+}
+
+function isObject(value: unknown): value is Record<string, unknown> {
+  return typeof value === "object" && value !== null && !Array.isArray(value);
+}
+
+function getPath(value: unknown, keys: string[]): unknown {
+  let current = value;
+  for (const key of keys) {
+    if (!isObject(current)) return undefined;
+    current = current[key];
+  }
+  return current;
+}
+
+function numberOr<T extends number | undefined>(value: unknown, fallback: T) {
+  return typeof value === "number" ? value : fallback;
+}
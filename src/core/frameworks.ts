@@ -0,0 +1,244 @@
+/**
+ * Framework detection with framework-specific prompt guidance.
+ * Frameworks are detected from dependency manifests between the file and the
+ * workspace root, and from imports in the file itself.
+ */
+
+import { existsSync, readFileSync, statSync } from "node:fs";
+import { basename, dirname, join, resolve } from "node:path";
+
+export interface FrameworkConfig {
+  id: string;
+  name: string;
+  languageId: string;
+  // Dependency names as they appear in manifests
+  packages: string[];
+  // Import of the framework in a source file
+  imports: RegExp;
+  // Only apply to files matching this pattern (e.g. components)
+  files?: RegExp;
+  promptInstructions: string;
+}
+
+const frameworks: FrameworkConfig[] = [
+  {
+    id: "react",
+    name: "React",
+    languageId: "typescript",
+    packages: ["react"],
+    imports: /from\s+["']react["']|require\(["']react["']\)/,
+    files: /\.[jt]sx$/,
+    promptInstructions: `- Rules of hooks: hooks only at the top level of components/custom hooks, never in conditions, loops or callbacks
+- Effect dependencies: missing or unstable dependencies in useEffect/useMemo/useCallback, effects without cleanup for subscriptions and timers
+- State: derived state copied into useState, direct state mutation, stale closures over state
+- Lists need stable keys (not array indexes when items reorder)`,
+  },
+  {
+    id: "nextjs",
+    name: "Next.js",
+    languageId: "typescript",
+    packages: ["next"],
+    imports: /from\s+["']next(?:\/[\w/-]+)?["']/,
+    promptInstructions: `- Server vs client components: browser APIs, state or effects in files without "use client"; secrets or server-only modules imported into client components
+- Data fetching: missing error handling and caching/revalidation options in server components and route handlers
+- Route handlers and server actions must validate input and check authorization`,
+  },
+  {
+    id: "express",
+    name: "Express",
+    languageId: "typescript",
+    packages: ["express"],
+    imports: /from\s+["']express["']|require\(["']express["']\)/,
+    promptInstructions: `- Async handlers: rejected promises are not caught by Express 4; errors must be passed to next(err) or handled
+- Every code path in a handler must send exactly one response; watch for missing return after res.send/res.json
+- Validate and sanitize req.params, req.query and req.body before use
+- Error-handling middleware needs four parameters (err, req, res, next)`,
+  },
+  {
+    id: "nestjs",
+    name: "NestJS",
+    languageId: "typescript",
+    packages: ["@nestjs/core", "@nestjs/common"],
+    imports: /from\s+["']@nestjs\/[\w-]+["']/,
+    promptInstructions: `- Throw Nest HTTP exceptions instead of returning error objects from controllers
+- Use DTOs with validation pipes for request bodies; avoid untyped @Body()
+- Keep business logic in providers, not controllers; inject dependencies instead of instantiating them`,
+  },
+  {
+    id: "gin",
+    name: "gin",
+    languageId: "go",
+    packages: ["github.com/gin-gonic/gin"],
+    imports: /"github\.com\/gin-gonic\/gin"/,
+    promptInstructions: `- Handlers must return after c.AbortWithStatus/c.JSON on error paths; execution continues otherwise
+- Check errors from c.ShouldBind*/c.Bind* and respond with 4xx
+- Pass c.Request.Context() (not c itself) to downstream I/O and goroutines; do not use *gin.Context after the handler returns`,
+  },
+  {
+    id: "echo",
+    name: "Echo",
+    languageId: "go",
+    packages: ["github.com/labstack/echo"],
+    imports: /"github\.com\/labstack\/echo(?:\/v\d+)?"/,
+    promptInstructions: `- Handlers return errors; return echo.NewHTTPError or the error instead of writing a response and returning nil
+- Check errors from c.Bind and validate input
+- Pass c.Request().Context() to downstream I/O for cancellation`,
+  },
+  {
+    id: "django",
+    name: "Django",
+    languageId: "python",
+    packages: ["django"],
+    imports: /^\s*(?:from|import)\s+django\b/m,
+    promptInstructions: `- Query efficiency: N+1 queries in loops (use select_related/prefetch_related), unbounded querysets
+- Security: raw SQL with string formatting, mark_safe on user input, missing permission checks in views
+- Use transactions for multi-step writes; avoid catching broad exceptions around ORM calls`,
+  },
+  {
+    id: "fastapi",
+    name: "FastAPI",
+    languageId: "python",
+    packages: ["fastapi"],
+    imports: /^\s*(?:from|import)\s+fastapi\b/m,
+    promptInstructions: `- Blocking I/O (requests, time.sleep, sync DB drivers) inside async def endpoints blocks the event loop
+- Use Pydantic models and dependencies for validation and auth instead of manual parsing
+- Raise HTTPException for client errors; do not return error dicts with status 200`,
+  },
+  {
+    id: "spring",
+    name: "Spring",
+    languageId: "java",
+    packages: ["spring-boot", "org.springframework"],
+    imports: /^\s*import\s+org\.springframework\./m,
+    promptInstructions: `- Prefer constructor injection over field @Autowired
+- @Transactional only works on public methods called through the proxy, not on self-invocation
+- Validate request bodies with @Valid; handle exceptions with @ControllerAdvice instead of per-method try/catch`,
+  },
+];
+
+const MANIFEST_FILES = [
+  "package.json",
+  "go.mod",
+  "pyproject.toml",
+  "requirements.txt",
+  "pom.xml",
+  "build.gradle",
+  "build.gradle.kts",
+];
+
+const DEPENDENCY_KEYS = [
+  "dependencies",
+  "devDependencies",
+  "peerDependencies",
+];
+
+interface Manifest {
+  packages?: Set<string>; // package.json dependency names
+  text?: string; // Other manifests are matched as text
+}
+
+// Parsed manifests keyed by path, invalidated when the file changes
+const manifestCache = new Map<
+  string,
+  { mtimeMs: number; manifest: Manifest }
+>();
+
+/**
+ * Detect frameworks that apply to a file.
+ */
+export function detectFrameworks(
+  filePath: string,
+  content: string,
+  languageId: string | undefined,
+  rootDir: string,
+): FrameworkConfig[] {
+  const candidates = frameworks.filter(
+    (f) =>
+      f.languageId === languageId && (!f.files || f.files.test(filePath)),
+  );
+  if (candidates.length === 0) {
+    return [];
+  }
+
+  const manifests = readManifests(filePath, rootDir);
+  return candidates.filter(
+    (f) =>
+      f.imports.test(content) ||
+      f.packages.some((pkg) => manifests.some((m) => declares(m, pkg))),
+  );
+}
+
+/**
+ * Get a framework config by ID.
+ */
+export function getFramework(id: string): FrameworkConfig | undefined {
+  return frameworks.find((f) => f.id === id);
+}
+
+/**
+ * Read every manifest between the file and the workspace root.
+ */
+function readManifests(filePath: string, rootDir: string): Manifest[] {
+  const root = resolve(rootDir);
+  const manifests: Manifest[] = [];
+  let dir = dirname(resolve(root, filePath));
+
+  while (true) {
+    for (const fileName of MANIFEST_FILES) {
+      const manifest = readManifest(join(dir, fileName));
+      if (manifest) manifests.push(manifest);
+    }
+
+    const parent = dirname(dir);
+    if (dir === root || parent === dir) {
+      return manifests;
+    }
+    dir = parent;
+  }
+}
+
+function readManifest(manifestPath: string): Manifest | null {
+  if (!existsSync(manifestPath)) {
+    return null;
+  }
+
+  try {
+    const mtimeMs = statSync(manifestPath).mtimeMs;
+    const cached = manifestCache.get(manifestPath);
+    if (cached && cached.mtimeMs === mtimeMs) {
+      return cached.manifest;
+    }
+
+    const text = readFileSync(manifestPath, "utf-8");
+    let manifest: Manifest = { text };
+    if (basename(manifestPath) === "package.json") {
+      // Only dependency names count, not scripts or descriptions
+      const pkg = JSON.parse(text) as Record<string, unknown>;
+      const packages = new Set<string>();
+      for (const key of DEPENDENCY_KEYS) {
+        const deps = pkg[key];
+        if (typeof deps === "object" && deps !== null) {
+          Object.keys(deps).forEach((name) => packages.add(name));
+        }
+      }
+      manifest = { packages };
+    }
+
+    manifestCache.set(manifestPath, { mtimeMs, manifest });
+    return manifest;
+  } catch {
+    return null;
+  }
+}
+
+function declares(manifest: Manifest, pkg: string): boolean {
+  if (manifest.packages) {
+    return manifest.packages.has(pkg);
+  }
+
+  // Module paths and artifact IDs, e.g. "github.com/labstack/echo/v4",
+  // "spring-boot-starter-web" or "Django>=4.2"
+  const escaped = pkg.replace(/[.*+?^${}()|[\]\\/]/g, "\\$&");
+  const pattern = new RegExp(`(?:^|[\\s"'=:<>/])${escaped}(?!\\w)`, "im");
+  return pattern.test(manifest.text ?? "");
+}
@@ -3,3 +3,17 @@ export * from "./debounce.js";
 export * from "./document-store.js";
 export * from "./diagnostics-mapper.js";
 export * from "./languages.js";
+export * from "./redactor.js";
+export * from "./egress-policy.js";
+export * from "./context-tools.js";
+export * from "./chunker.js";
+export * from "./embedding-index.js";
+export * from "./verifier.js";
+export * from "./units.js";
+export * from "./frameworks.js";
+export * from "./external-results.js";
+export * from "./triage.js";
+export * from "./coverage.js";
+export * from "./codeowners.js";
+export * from "./notebooks.js";
+export * from "./skip-detect.js";
@@ -1,13 +1,28 @@
 /**
  * Simple language detection and configuration.
- * No tree-sitter, just extension-based detection with language-specific prompt rules.
+ * No tree-sitter, just detection by file name, extension and, for shared
+ * extensions like .yaml, content, with language-specific prompt rules.
  */
 
+import { existsSync, readFileSync, statSync } from "node:fs";
+import { dirname, extname, join, resolve } from "node:path";
+import { matchGlob } from "../utils/glob-match.js";
+import type { SqlDialect } from "../types/config.js";
+
 export interface LanguageConfig {
   id: string;
   name: string;
   extensions: string[];
+  languageIds: string[]; // LSP language identifiers, for unsaved buffers
+  // Globs for files the extension does not identify, e.g. "Dockerfile"
+  filePatterns?: string[];
+  // Files with these extensions are this language if the content matches
+  sniff?: { extensions: string[]; test: (content: string) => boolean };
   promptInstructions: string;
+  // Project files that pin toolchain versions; the nearest one wins
+  projectFiles?: string[];
+  // Turn a project file into facts and guidance for the prompt
+  parseProjectFacts?: (fileName: string, content: string) => string[];
 }
 
 /**
@@ -18,50 +33,142 @@ const languages: LanguageConfig[] = [
     id: "typescript",
     name: "TypeScript",
     extensions: [".ts", ".tsx", ".js", ".jsx"],
+    languageIds: [
+      "typescript",
+      "typescriptreact",
+      "javascript",
+      "javascriptreact",
+    ],
     promptInstructions: `You are analyzing TypeScript/JavaScript code. Pay attention to:
 - Type safety: Watch for 'any' type abuse and unsafe type assertions
 - Async/await patterns: Look for unhandled promises and missing error handling
-- Null/undefined handling: Missing optional chaining or nullish coalescing
-- React patterns (for .tsx/.jsx): Component structure, hooks rules`,
+- Null/undefined handling: Missing optional chaining or nullish coalescing`,
+    projectFiles: ["tsconfig.json", "jsconfig.json"],
+    parseProjectFacts: parseTSConfig,
   },
   {
     id: "go",
     name: "Go",
     extensions: [".go"],
+    languageIds: ["go"],
     promptInstructions: `You are analyzing Go code. Pay special attention to:
 - Error handling: EVERY error must be checked. Look for _ = err or missing if err != nil
 - Context propagation: Functions doing I/O should accept context.Context as first param
 - Goroutines: Check for proper synchronization, channel handling, and goroutine leaks
 - Defer patterns: Resources (files, mutexes, connections) should use defer for cleanup
 - Interface design: Prefer small interfaces. Avoid interface{}/any unless necessary`,
+    projectFiles: ["go.mod"],
+    parseProjectFacts: parseGoMod,
   },
   {
     id: "python",
     name: "Python",
     extensions: [".py"],
+    languageIds: ["python"],
     promptInstructions: `You are analyzing Python code. Pay attention to:
 - Type hints: Missing or incorrect type annotations
 - Exception handling: Bare except clauses, swallowed exceptions
 - Resource management: Missing context managers (with statements)
 - Mutable default arguments: Lists/dicts as default parameter values`,
+    projectFiles: ["pyproject.toml", "setup.cfg"],
+    parseProjectFacts: parsePythonProject,
   },
   {
     id: "rust",
     name: "Rust",
     extensions: [".rs"],
+    languageIds: ["rust"],
     promptInstructions: `You are analyzing Rust code. Pay attention to:
 - Error handling: Proper use of Result and Option, unwrap() abuse
 - Memory safety: Unnecessary clones, lifetime issues
 - Concurrency: Proper use of Arc, Mutex, channels`,
+    projectFiles: ["Cargo.toml"],
+    parseProjectFacts: parseCargoToml,
   },
   {
     id: "java",
     name: "Java",
     extensions: [".java"],
+    languageIds: ["java"],
     promptInstructions: `You are analyzing Java code. Pay attention to:
 - Null safety: Missing null checks, potential NullPointerException
 - Resource management: Missing try-with-resources
 - Exception handling: Empty catch blocks, catching generic Exception`,
+    projectFiles: ["pom.xml", "build.gradle", "build.gradle.kts"],
+    parseProjectFacts: parseJavaBuild,
+  },
+  {
+    id: "sql",
+    name: "SQL",
+    extensions: [".sql"],
+    languageIds: ["sql", "pgsql", "mysql"],
+    promptInstructions: `You are analyzing SQL. Files under migrations/, db/migrate/ or named like *.up.sql are schema migrations. Pay attention to:
+- Locking operations: ALTERs that rewrite or lock large tables (adding NOT NULL columns with defaults, changing column types, non-concurrent index builds)
+- Missing indexes: Foreign key columns without an index, queries filtering on unindexed columns
+- Non-reversible migrations: DROP TABLE/COLUMN or data-changing UPDATEs with no way back, missing down migrations
+- Data loss: Narrowing column types, dropping constraints that protect data, DELETE/UPDATE without WHERE
+- Fragile queries: SELECT * in views and inserts, implicit column order, NOT IN with nullable subqueries`,
+  },
+  {
+    id: "dockerfile",
+    name: "Dockerfile",
+    extensions: [".dockerfile"],
+    languageIds: ["dockerfile"],
+    filePatterns: [
+      "Dockerfile",
+      "Dockerfile.*",
+      "*.Dockerfile",
+      "Containerfile",
+    ],
+    promptInstructions: `You are analyzing a Dockerfile. Pay attention to:
+- Running as root: No USER instruction, or USER root in the final stage
+- Unpinned images: FROM without a tag, with :latest, or without a digest for production images
+- Secrets: Credentials in ENV, ARG or copied files; they stay in the image layers
+- Supply chain: curl | sh, ADD from URLs, packages installed without versions
+- Image size: Package caches not cleaned in the same RUN, missing multi-stage builds`,
+  },
+  {
+    id: "terraform",
+    name: "Terraform",
+    extensions: [".tf", ".tfvars"],
+    languageIds: ["terraform", "terraform-vars"],
+    promptInstructions: `You are analyzing Terraform code. Pay attention to:
+- Overly broad IAM: "*" actions or resources, admin policies attached to workloads
+- Network exposure: 0.0.0.0/0 ingress, public buckets, databases with public access
+- Secrets in plaintext: Passwords and keys in variables defaults, tfvars or resource arguments
+- Unpinned versions: Providers and modules without version constraints or refs
+- Missing protection: Encryption at rest disabled, no deletion protection or backups on stateful resources`,
+  },
+  {
+    id: "github-actions",
+    name: "GitHub Actions workflow",
+    extensions: [],
+    languageIds: ["github-actions-workflow"],
+    filePatterns: [".github/workflows/*.yml", ".github/workflows/*.yaml"],
+    promptInstructions: `You are analyzing a GitHub Actions workflow. Pay attention to:
+- Unpinned actions: uses: with a branch or tag instead of a full commit SHA, especially third-party actions
+- Permissions: Missing top-level permissions (defaults may be write-all) or broader permissions than the jobs need
+- Script injection: \${{ github.event.* }} or other untrusted input interpolated directly into run: scripts
+- pull_request_target: Checking out and running the pull request's code with secrets available
+- Secrets: Secrets echoed to logs, passed to untrusted actions, or hardcoded`,
+  },
+  {
+    id: "kubernetes",
+    name: "Kubernetes manifest",
+    extensions: [],
+    languageIds: [],
+    sniff: {
+      extensions: [".yaml", ".yml"],
+      test: (content) =>
+        /^apiVersion:\s*\S/m.test(content) && /^kind:\s*\S/m.test(content),
+    },
+    promptInstructions: `You are analyzing Kubernetes manifests. Pay attention to:
+- Missing resource limits: Containers without CPU/memory requests and limits
+- Running as root: No runAsNonRoot, privileged: true, allowPrivilegeEscalation, added capabilities, hostPath, hostNetwork
+- Unpinned images: :latest or missing tags, imagePullPolicy that hides changes
+- Secrets in plaintext: Credentials in env values or ConfigMaps instead of Secrets
+- Overly broad RBAC: "*" verbs or resources, cluster-admin bindings for workloads
+- Reliability: Missing liveness/readiness probes, single replicas for services`,
   },
 ];
 
@@ -85,13 +192,57 @@ export function getLanguageForExtension(
 }
 
 /**
- * Get language config by file path.
+ * Get language config by LSP language identifier, e.g. "typescriptreact".
+ */
+export function getLanguageForLanguageId(
+  languageId: string,
+): LanguageConfig | undefined {
+  return languages.find((lang) => lang.languageIds.includes(languageId));
+}
+
+/**
+ * Get language config by its id, e.g. "go" or "dockerfile".
+ */
+export function getLanguageById(id: string): LanguageConfig | undefined {
+  return languages.find((lang) => lang.id === id);
+}
+
+/**
+ * Get language config by file path: file name patterns first, then the
+ * extension, then the content for extensions several languages share.
  */
 export function getLanguageForFile(
   filePath: string,
+  content?: string,
 ): LanguageConfig | undefined {
-  const ext = filePath.slice(filePath.lastIndexOf("."));
-  return getLanguageForExtension(ext);
+  const byPattern = languages.find((lang) =>
+    lang.filePatterns?.some((pattern) => matchPathSuffix(filePath, pattern)),
+  );
+  if (byPattern) {
+    return byPattern;
+  }
+
+  const ext = extname(filePath);
+  const byExtension = getLanguageForExtension(ext);
+  if (byExtension || content === undefined) {
+    return byExtension;
+  }
+
+  return languages.find(
+    (lang) => lang.sniff?.extensions.includes(ext) && lang.sniff.test(content),
+  );
+}
+
+/**
+ * Match a pattern like ".github/workflows/*.yml" against the end of a path,
+ * since the path may be absolute and the workspace root is not known here.
+ * Patterns name files, so a file below a matching directory does not match.
+ */
+function matchPathSuffix(filePath: string, pattern: string): boolean {
+  const segments = filePath.replace(/\\/g, "/").split("/");
+  return segments.some((_, i) =>
+    matchGlob(segments.slice(i).join("/"), pattern, { directories: false }),
+  );
 }
 
 /**
@@ -107,3 +258,301 @@ export function isExtensionSupported(ext: string): boolean {
 export function getSupportedExtensions(): string[] {
   return Array.from(extensionMap.keys());
 }
+
+// ============================================================================
+// Project Facts
+// ============================================================================
+
+// Parsed facts keyed by project file path, invalidated when the file changes
+const projectFactsCache = new Map<
+  string,
+  { mtimeMs: number; facts: string[] }
+>();
+
+/**
+ * Detect toolchain versions and project settings that apply to a file, from
+ * the nearest project file (go.mod, tsconfig.json, pyproject.toml, ...)
+ * between the file and the workspace root.
+ */
+export function detectProjectFacts(
+  filePath: string,
+  languageId: string | undefined,
+  rootDir: string,
+): string[] {
+  const lang = languages.find((l) => l.id === languageId);
+  if (!lang?.projectFiles || !lang.parseProjectFacts) {
+    return [];
+  }
+
+  const root = resolve(rootDir);
+  let dir = dirname(resolve(root, filePath));
+
+  while (true) {
+    for (const fileName of lang.projectFiles) {
+      const projectFile = join(dir, fileName);
+      if (existsSync(projectFile)) {
+        return readProjectFacts(projectFile, fileName, lang.parseProjectFacts);
+      }
+    }
+
+    const parent = dirname(dir);
+    if (dir === root || parent === dir) {
+      return [];
+    }
+    dir = parent;
+  }
+}
+
+function readProjectFacts(
+  projectFile: string,
+  fileName: string,
+  parse: (fileName: string, content: string) => string[],
+): string[] {
+  try {
+    const mtimeMs = statSync(projectFile).mtimeMs;
+    const cached = projectFactsCache.get(projectFile);
+    if (cached && cached.mtimeMs === mtimeMs) {
+      return cached.facts;
+    }
+
+    const facts = parse(fileName, readFileSync(projectFile, "utf-8"));
+    projectFactsCache.set(projectFile, { mtimeMs, facts });
+    return facts;
+  } catch {
+    return [];
+  }
+}
+
+const SQL_DIALECT_FACTS: Record<SqlDialect, string[]> = {
+  postgres: [
+    "SQL dialect: PostgreSQL",
+    "CREATE INDEX without CONCURRENTLY blocks writes for the whole build; CONCURRENTLY cannot run inside a transaction",
+    "Adding a column with a volatile default or changing a column type rewrites the table under an ACCESS EXCLUSIVE lock",
+    "DDL is transactional, so a failed migration rolls back completely",
+  ],
+  mysql: [
+    "SQL dialect: MySQL",
+    "DDL is not transactional: a migration that fails halfway leaves the earlier statements applied",
+    "ALTER TABLE copies the table unless ALGORITHM=INSTANT or INPLACE applies; prefer statements that allow them",
+    "Foreign keys need an index on the referencing columns; InnoDB creates one implicitly if missing",
+  ],
+  sqlite: [
+    "SQL dialect: SQLite",
+    "ALTER TABLE supports only renames and adding or dropping columns; other changes need a table rebuild",
+    "Foreign keys are only enforced with PRAGMA foreign_keys = ON",
+  ],
+  sqlserver: [
+    "SQL dialect: SQL Server (T-SQL)",
+    "Index builds lock the table unless WITH (ONLINE = ON) is used, which needs Enterprise edition",
+    "Schema changes take a schema modification lock that blocks all reads and writes on the table",
+  ],
+};
+
+/**
+ * Facts about the configured SQL dialect, for the project settings section
+ * of the prompt.
+ */
+export function sqlDialectFacts(dialect: SqlDialect | undefined): string[] {
+  return dialect ? SQL_DIALECT_FACTS[dialect] : [];
+}
+
+/**
+ * Compare dotted version strings, e.g. versionAtLeast("1.17", "1.18").
+ */
+export function versionAtLeast(version: string, minimum: string): boolean {
+  const a = version.split(".").map((n) => parseInt(n, 10) || 0);
+  const b = minimum.split(".").map((n) => parseInt(n, 10) || 0);
+  for (let i = 0; i < Math.max(a.length, b.length); i++) {
+    const diff = (a[i] ?? 0) - (b[i] ?? 0);
+    if (diff !== 0) return diff > 0;
+  }
+  return true;
+}
+
+function parseGoMod(_fileName: string, content: string): string[] {
+  const match = content.match(/^go\s+(\d+\.\d+(?:\.\d+)?)\s*$/m);
+  if (!match) {
+    return [];
+  }
+
+  const version = match[1];
+  const facts = [`Go version: ${version} (go directive in go.mod)`];
+  if (!versionAtLeast(version, "1.18")) {
+    facts.push(
+      "Generics and the `any` alias are not available before Go 1.18. Do not suggest replacing interface{} with any or using type parameters",
+    );
+  }
+  if (!versionAtLeast(version, "1.20")) {
+    facts.push("errors.Join is not available before Go 1.20");
+  }
+  if (!versionAtLeast(version, "1.21")) {
+    facts.push(
+      "The min/max/clear builtins and the slices, maps and log/slog packages are not in the standard library before Go 1.21",
+    );
+  }
+  if (versionAtLeast(version, "1.22")) {
+    facts.push(
+      "Loop variables are per-iteration since Go 1.22. Do not report loop variable capture in closures or goroutines",
+    );
+  } else {
+    facts.push(
+      "Loop variables are shared across iterations before Go 1.22, so capturing them in goroutines or closures is a real bug",
+    );
+  }
+  return facts;
+}
+
+function parseTSConfig(fileName: string, content: string): string[] {
+  let tsconfig: { compilerOptions?: Record<string, unknown> };
+  try {
+    tsconfig = JSON.parse(stripJSONComments(content));
+  } catch {
+    return [];
+  }
+
+  const options = tsconfig.compilerOptions ?? {};
+  const facts: string[] = [];
+
+  if (typeof options["strict"] === "boolean") {
+    facts.push(
+      options["strict"]
+        ? `Strict mode is on (${fileName}): implicit any and unchecked null/undefined are already compiler errors`
+        : `Strict mode is off (${fileName}): null/undefined are not checked by the compiler, so missing null checks matter more`,
+    );
+  }
+  if (options["noUncheckedIndexedAccess"] === true) {
+    facts.push(
+      "noUncheckedIndexedAccess is on: indexed access may be undefined",
+    );
+  }
+
+  const target =
+    typeof options["target"] === "string" ? options["target"] : undefined;
+  if (target) {
+    facts.push(`Compile target: ${target}`);
+    const year = /^es(\d{4})$/i.exec(target)?.[1];
+    const isOld =
+      /^es[356]$/i.test(target) || (year !== undefined && Number(year) < 2020);
+    if (isOld) {
+      facts.push(
+        `Do not suggest runtime APIs newer than ${target} without a polyfill, and in plain .js files do not suggest syntax newer than ${target} such as optional chaining (?.) or nullish coalescing (??)`,
+      );
+    }
+  }
+
+  const module =
+    typeof options["module"] === "string" ? options["module"] : undefined;
+  if (module) {
+    facts.push(`Module system: ${module}`);
+  }
+
+  return facts;
+}
+
+function parsePythonProject(_fileName: string, content: string): string[] {
+  const match =
+    content.match(/^\s*requires-python\s*=\s*["']([^"']+)["']/m) ??
+    content.match(/^\s*python_requires\s*=\s*(.+)$/m) ??
+    content.match(/^\s*python\s*=\s*["']([^"']+)["']/m);
+  if (!match) {
+    return [];
+  }
+
+  const spec = match[1].trim();
+  const facts = [`Supported Python versions: ${spec}`];
+  const minimum = spec.match(/(\d+\.\d+)/)?.[1];
+  if (minimum && !spec.startsWith("<")) {
+    if (!versionAtLeast(minimum, "3.9")) {
+      facts.push(
+        "Built-in generic annotations like list[int] need `from __future__ import annotations` before Python 3.9; typing.List is fine",
+      );
+    }
+    if (!versionAtLeast(minimum, "3.10")) {
+      facts.push(
+        "match statements and X | Y union syntax are not available before Python 3.10. Do not suggest them",
+      );
+    }
+  }
+  return facts;
+}
+
+function parseCargoToml(_fileName: string, content: string): string[] {
+  const facts: string[] = [];
+  const edition = content.match(/^\s*edition\s*=\s*"(\d{4})"/m)?.[1];
+  const rustVersion = content.match(
+    /^\s*rust-version\s*=\s*"([\d.]+)"/m,
+  )?.[1];
+
+  if (edition) {
+    facts.push(`Rust edition: ${edition}`);
+  } else if (/^\s*\[package\]/m.test(content)) {
+    facts.push(
+      "Rust edition: 2015 (no edition set in Cargo.toml)",
+    );
+  }
+  if (rustVersion) {
+    facts.push(
+      `Minimum supported Rust version: ${rustVersion}. Do not suggest language features or std APIs stabilized later`,
+    );
+  }
+  return facts;
+}
+
+function parseJavaBuild(fileName: string, content: string): string[] {
+  const match = fileName.endsWith(".xml")
+    ? content.match(
+        /<(?:maven\.compiler\.release|maven\.compiler\.source|release|java\.version)>\s*(?:1\.)?(\d+)\s*</,
+      )
+    : content.match(
+        /(?:sourceCompatibility\s*=\s*(?:JavaVersion\.VERSION_(?:1_)?|["']?(?:1\.)?)|JavaLanguageVersion\.of\()(\d+)/,
+      );
+  if (!match) {
+    return [];
+  }
+
+  const version = match[1];
+  const facts = [`Java version: ${version} (${fileName})`];
+  if (Number(version) < 17) {
+    facts.push(
+      `Records, sealed classes, switch expressions and pattern matching for instanceof may not be available in Java ${version}. Do not suggest features newer than the project's Java version`,
+    );
+  }
+  return facts;
+}
+
+/**
+ * Strip // and /* comments and trailing commas from JSONC (tsconfig.json).
+ */
+function stripJSONComments(content: string): string {
+  let result = "";
+  let inString = false;
+
+  for (let i = 0; i < content.length; i++) {
+    const char = content[i];
+    const next = content[i + 1];
+
+    if (inString) {
+      result += char;
+      if (char === "\\") {
+        result += next ?? "";
+        i++;
+      } else if (char === '"') {
+        inString = false;
+      }
+    } else if (char === '"') {
+      inString = true;
+      result += char;
+    } else if (char === "/" && next === "/") {
+      while (i < content.length && content[i] !== "\n") i++;
+      result += "\n";
+    } else if (char === "/" && next === "*") {
+      i += 2;
+      while (i < content.length && content.slice(i, i + 2) !== "*/") i++;
+      i++;
+    } else {
+      result += char;
+    }
+  }
+
+  return result.replace(/,(\s*[}\]])/g, "$1");
+}
@@ -0,0 +1,95 @@
+/**
+ * Jupyter notebooks. Code cells are joined into one Python document with a
+ * "# %% [cell N]" line before each cell (the percent format editors and
+ * jupytext understand), and findings are mapped back to cell and line.
+ */
+
+import type { Finding } from "../types/finding.js";
+
+export interface NotebookCell {
+  cell: number; // 1-based, counting markdown and raw cells like editors do
+  startLine: number; // First line of the cell's code in the document
+  lineCount: number;
+}
+
+export interface NotebookDocument {
+  content: string;
+  cells: NotebookCell[];
+}
+
+export function isNotebook(filePath: string): boolean {
+  return filePath.toLowerCase().endsWith(".ipynb");
+}
+
+/**
+ * Extract the code cells of a notebook (nbformat 4 JSON). Outputs are left
+ * out. Throws if the text is not a notebook.
+ */
+export function notebookToDocument(text: string): NotebookDocument {
+  const notebook = JSON.parse(text);
+  if (!Array.isArray(notebook?.cells)) {
+    throw new Error("no cells (only nbformat 4 is supported)");
+  }
+
+  const rawCells: Array<{ cell_type?: string; source?: string | string[] }> =
+    notebook.cells;
+  const lines: string[] = [];
+  const cells: NotebookCell[] = [];
+  rawCells.forEach((raw, i) => {
+    if (raw.cell_type !== "code") return;
+
+    const source = Array.isArray(raw.source)
+      ? raw.source.join("")
+      : (raw.source ?? "");
+    const code = source.replace(/\r?\n$/, "").split(/\r?\n/);
+
+    lines.push(`# %% [cell ${i + 1}]`);
+    cells.push({
+      cell: i + 1,
+      startLine: lines.length,
+      lineCount: code.length,
+    });
+    lines.push(...code);
+  });
+
+  return { content: lines.join("\n"), cells };
+}
+
+/**
+ * Map a finding on the document back to its cell, with lines relative to
+ * the cell. A range that starts on a cell marker moves to the cell's first
+ * line; one that runs into the next cell ends at the cell's last line.
+ */
+export function mapToCell(
+  finding: Finding,
+  notebook: NotebookDocument,
+): Finding {
+  if (!finding.range || notebook.cells.length === 0) {
+    return finding;
+  }
+
+  const { startLine } = finding.range;
+  const cell =
+    [...notebook.cells].reverse().find((c) => c.startLine - 1 <= startLine) ??
+    notebook.cells[0];
+  const lastLine = cell.lineCount - 1;
+  const toCell = (line: number) =>
+    Math.min(Math.max(line - cell.startLine, 0), lastLine);
+  const endLine = toCell(finding.range.endLine);
+  const pastCell = finding.range.endLine - cell.startLine > lastLine;
+
+  return {
+    ...finding,
+    cell: cell.cell,
+    range: {
+      startLine: toCell(startLine),
+      startCharacter:
+        startLine < cell.startLine ? 0 : finding.range.startCharacter,
+      endLine,
+      endCharacter: pastCell
+        ? Array.from(notebook.content.split("\n")[cell.startLine + endLine])
+            .length
+        : finding.range.endCharacter,
+    },
+  };
+}
@@ -0,0 +1,87 @@
+/**
+ * Text positions in findings and how editors count them.
+ *
+ * The model reports columns as the characters it sees, i.e. Unicode code
+ * points with a tab counting as one. LSP clients count UTF-16 code units
+ * unless another encoding is negotiated, so an emoji or a non-BMP
+ * identifier earlier on the line shifts every column after it.
+ */
+
+import type { Range } from "../types/finding.js";
+
+export type PositionEncoding = "utf-8" | "utf-16" | "utf-32";
+
+const SUPPORTED_ENCODINGS: PositionEncoding[] = ["utf-8", "utf-16", "utf-32"];
+
+/**
+ * Pick the position encoding from the client's `general.positionEncodings`,
+ * in the client's order of preference. UTF-16 is the LSP default and the
+ * only encoding every client supports.
+ */
+export function negotiatePositionEncoding(
+  offered: string[] | undefined,
+): PositionEncoding {
+  const match = offered?.find((encoding): encoding is PositionEncoding =>
+    SUPPORTED_ENCODINGS.includes(encoding as PositionEncoding),
+  );
+  return match ?? "utf-16";
+}
+
+/**
+ * Split text into lines without their terminators. Accepts \n, \r\n and a
+ * lone \r, as LSP does, so a CRLF file does not get a trailing \r per line.
+ */
+export function splitLines(text: string): string[] {
+  return text.split(/\r\n|\r|\n/);
+}
+
+/**
+ * Convert a code point column on a line into the encoding's code units.
+ * Columns past the end of the line map to the end of the line.
+ */
+export function encodeColumn(
+  line: string,
+  column: number,
+  encoding: PositionEncoding,
+): number {
+  const prefix = Array.from(line).slice(0, Math.max(0, column)).join("");
+  switch (encoding) {
+    case "utf-8":
+      return Buffer.byteLength(prefix, "utf-8");
+    case "utf-16":
+      return prefix.length;
+    case "utf-32":
+      return Array.from(prefix).length;
+  }
+}
+
+/**
+ * Fit a model-reported range to the text: clamp lines and columns, move a
+ * start inside the indentation to the first code character (models often
+ * miscount tabs) and widen an empty or inverted single-line range to the
+ * end of the line's code.
+ */
+export function normalizeRange(range: Range, lines: string[]): Range {
+  const lastLine = Math.max(0, lines.length - 1);
+  const startLine = Math.min(range.startLine, lastLine);
+  const endLine = Math.min(Math.max(range.endLine, startLine), lastLine);
+
+  const startText = Array.from(lines[startLine] ?? "");
+  const endText = Array.from(lines[endLine] ?? "");
+
+  let startCharacter = Math.min(range.startCharacter, startText.length);
+  const indent = startText.findIndex((char) => !/\s/.test(char));
+  if (indent > 0 && startCharacter < indent) {
+    startCharacter = indent;
+  }
+
+  let endCharacter = Math.min(range.endCharacter, endText.length);
+  if (endLine === startLine && endCharacter <= startCharacter) {
+    endCharacter = Math.max(
+      startCharacter,
+      Array.from(endText.join("").trimEnd()).length,
+    );
+  }
+
+  return { startLine, startCharacter, endLine, endCharacter };
+}
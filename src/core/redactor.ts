@@ -0,0 +1,287 @@
+/**
+ * Secret and PII scrubbing for code that is sent to an LLM.
+ *
+ * Detected secrets are replaced with stable placeholders before the prompt is
+ * built, and placeholders in the model's answer are mapped back afterwards.
+ * Newlines inside a secret are preserved so line numbers stay valid.
+ */
+
+import type { Finding, Range } from "../types/finding.js";
+import type { PrivacyConfig } from "../types/config.js";
+import { logger } from "../utils/logger.js";
+
+export interface RedactionPattern {
+  kind: string;
+  // If the regex has a group named "secret", only that group is replaced
+  regex: RegExp;
+}
+
+/**
+ * Built-in patterns for common credentials.
+ */
+const BUILTIN_PATTERNS: RedactionPattern[] = [
+  {
+    kind: "PRIVATE_KEY",
+    regex:
+      /-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----/g,
+  },
+  { kind: "AWS_ACCESS_KEY", regex: /\b(?:AKIA|ASIA)[0-9A-Z]{16}\b/g },
+  { kind: "GITHUB_TOKEN", regex: /\bgh[pousr]_[A-Za-z0-9]{36,}\b/g },
+  { kind: "GITHUB_TOKEN", regex: /\bgithub_pat_[A-Za-z0-9_]{40,}\b/g },
+  { kind: "API_KEY", regex: /\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}/g },
+  { kind: "API_KEY", regex: /\b[rs]k_live_[A-Za-z0-9]{16,}\b/g },
+  { kind: "API_KEY", regex: /\bAIza[0-9A-Za-z_-]{35}\b/g },
+  { kind: "SLACK_TOKEN", regex: /\bxox[abposr]-[A-Za-z0-9-]{10,}\b/g },
+  {
+    kind: "JWT",
+    regex: /\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}/g,
+  },
+  {
+    kind: "CONNECTION_STRING",
+    regex:
+      /\b(?:postgres(?:ql)?|mysql|mariadb|mongodb(?:\+srv)?|redis|rediss|amqps?|mssql|sqlserver):\/\/(?<secret>[^\s:@/'"`]+:[^\s@'"`]+)@/g,
+  },
+  {
+    kind: "SECRET",
+    regex:
+      /\b(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|client[_-]?secret|auth)\w*["']?\s*[:=]\s*["'`](?<secret>[^"'`\s]{8,})["'`]/gi,
+  },
+  {
+    // Unquoted values in .env, YAML and properties files run to the end of
+    // the line; values with calls are code. Keys are bounded so long lines
+    // cannot backtrack for long.
+    kind: "SECRET",
+    regex:
+      /^[ \t]*(?:export[ \t]+)?[\w.-]{0,40}?(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|client[_-]?secret|auth)[\w.-]{0,40}[ \t]*[:=][ \t]*(?<secret>[^\s"'`$([{][^\r\n(]{7,}?)[ \t]*$/gim,
+  },
+  {
+    kind: "EMAIL",
+    regex:
+      /\b[A-Za-z0-9._%+-]{1,64}@[A-Za-z0-9-]{1,63}(?:\.[A-Za-z0-9-]{1,63}){0,8}\.[A-Za-z]{2,24}\b/g,
+  },
+];
+
+/**
+ * A single replaced span, in offsets of the original and the redacted text.
+ */
+interface Replacement {
+  originalStart: number;
+  originalEnd: number;
+  redactedStart: number;
+  redactedEnd: number;
+}
+
+export interface RedactedText {
+  text: string;
+  count: number;
+  original: string;
+  replacements: Replacement[];
+}
+
+/**
+ * Redacts secrets from text. Placeholders are stable: the same secret value
+ * always maps to the same placeholder within one Redactor instance.
+ */
+export class Redactor {
+  private patterns: RedactionPattern[];
+  private placeholders = new Map<string, string>(); // secret -> placeholder
+  private originals = new Map<string, string>(); // placeholder -> secret
+  private counters = new Map<string, number>();
+
+  constructor(patterns: RedactionPattern[]) {
+    this.patterns = patterns;
+  }
+
+  /**
+   * Replace all detected secrets in text with placeholders.
+   */
+  redact(text: string): RedactedText {
+    const spans = this.findSpans(text);
+    const replacements: Replacement[] = [];
+    let output = "";
+    let cursor = 0;
+
+    for (const span of spans) {
+      const secret = text.slice(span.start, span.end);
+      const placeholder = this.placeholderFor(span.kind, secret);
+      // Keep line structure intact for multi-line secrets (e.g. PEM blocks)
+      const newlines = secret.match(/\r?\n/g)?.join("") ?? "";
+      const replacement = placeholder + newlines;
+
+      output += text.slice(cursor, span.start);
+      replacements.push({
+        originalStart: span.start,
+        originalEnd: span.end,
+        redactedStart: output.length,
+        redactedEnd: output.length + replacement.length,
+      });
+      output += replacement;
+      cursor = span.end;
+    }
+    output += text.slice(cursor);
+
+    return {
+      text: output,
+      count: replacements.length,
+      original: text,
+      replacements,
+    };
+  }
+
+  /**
+   * Replace placeholders in text with the original secret values.
+   */
+  restore(text: string): string {
+    if (this.originals.size === 0) return text;
+    return text.replace(/REDACTED_[A-Z_]+_\d+/g, (match) => {
+      return this.originals.get(match) ?? match;
+    });
+  }
+
+  /**
+   * Map a finding produced against redacted text back to the original text.
+   */
+  restoreFinding(finding: Finding, redacted: RedactedText): Finding {
+    return {
+      ...finding,
+      title: this.restore(finding.title),
+      message: this.restore(finding.message),
+      suggestion: this.restore(finding.suggestion),
+      range: finding.range
+        ? restoreRange(finding.range, redacted)
+        : finding.range,
+    };
+  }
+
+  private placeholderFor(kind: string, secret: string): string {
+    const existing = this.placeholders.get(secret);
+    if (existing) return existing;
+
+    const n = (this.counters.get(kind) ?? 0) + 1;
+    this.counters.set(kind, n);
+    const placeholder = `REDACTED_${kind}_${n}`;
+    this.placeholders.set(secret, placeholder);
+    this.originals.set(placeholder, secret);
+    return placeholder;
+  }
+
+  private findSpans(
+    text: string,
+  ): Array<{ start: number; end: number; kind: string }> {
+    const spans: Array<{ start: number; end: number; kind: string }> = [];
+
+    for (const pattern of this.patterns) {
+      const regex = new RegExp(
+        pattern.regex.source,
+        pattern.regex.flags.includes("g")
+          ? pattern.regex.flags
+          : pattern.regex.flags + "g",
+      );
+      for (const match of text.matchAll(regex)) {
+        if (match.index === undefined || match[0].length === 0) continue;
+        const secret = match.groups?.["secret"];
+        const start = secret
+          ? match.index + match[0].indexOf(secret)
+          : match.index;
+        const end = start + (secret ?? match[0]).length;
+        spans.push({ start, end, kind: pattern.kind });
+      }
+    }
+
+    // Sort and drop spans that overlap an earlier (or longer) one
+    spans.sort((a, b) => a.start - b.start || b.end - a.end);
+    const result: typeof spans = [];
+    for (const span of spans) {
+      const last = result[result.length - 1];
+      if (last && span.start < last.end) continue;
+      result.push(span);
+    }
+    return result;
+  }
+}
+
+/**
+ * Map a range in redacted text back to the original text. Lines are preserved
+ * by redaction, so only character offsets on affected lines shift.
+ */
+function restoreRange(range: Range, redacted: RedactedText): Range {
+  if (redacted.count === 0) return range;
+
+  const redactedLines = lineOffsets(redacted.text);
+  const originalLines = lineOffsets(redacted.original);
+
+  const mapPosition = (
+    line: number,
+    character: number,
+  ): { line: number; character: number } => {
+    if (line >= redactedLines.length) return { line, character };
+    const offset = redactedLines[line] + character;
+    const mapped = mapOffset(offset, redacted.replacements);
+    return offsetToPosition(mapped, originalLines);
+  };
+
+  const start = mapPosition(range.startLine, range.startCharacter);
+  const end = mapPosition(range.endLine, range.endCharacter);
+  return {
+    startLine: start.line,
+    startCharacter: start.character,
+    endLine: end.line,
+    endCharacter: end.character,
+  };
+}
+
+function mapOffset(offset: number, replacements: Replacement[]): number {
+  let delta = 0;
+  for (const r of replacements) {
+    if (offset < r.redactedStart) break;
+    if (offset < r.redactedEnd) {
+      // Inside a placeholder - snap to the start of the original secret
+      return r.originalStart;
+    }
+    delta = r.originalEnd - r.redactedEnd;
+  }
+  return offset + delta;
+}
+
+function lineOffsets(text: string): number[] {
+  const offsets = [0];
+  for (let i = 0; i < text.length; i++) {
+    if (text[i] === "\n") offsets.push(i + 1);
+  }
+  return offsets;
+}
+
+function offsetToPosition(
+  offset: number,
+  lines: number[],
+): { line: number; character: number } {
+  let line = 0;
+  while (line + 1 < lines.length && lines[line + 1] <= offset) {
+    line++;
+  }
+  return { line, character: offset - lines[line] };
+}
+
+/**
+ * Create a Redactor from the privacy config.
+ * Returns null if redaction is disabled.
+ */
+export function createRedactor(config: PrivacyConfig): Redactor | null {
+  if (!config.redactSecrets) {
+    return null;
+  }
+
+  const patterns = [...BUILTIN_PATTERNS];
+  for (const [index, source] of config.redactPatterns.entries()) {
+    try {
+      patterns.push({ kind: "CUSTOM", regex: new RegExp(source, "g") });
+    } catch (error) {
+      logger.warn(
+        `Invalid redaction pattern #${index + 1}: ${source}`,
+        error instanceof Error ? error.message : error,
+      );
+    }
+  }
+
+  return new Redactor(patterns);
+}
@@ -0,0 +1,109 @@
+/**
+ * Files nobody should edit by hand: generated code, vendored dependencies,
+ * minified bundles and lockfiles. Reviewing them only wastes tokens.
+ */
+
+import { basename } from "node:path";
+import { toWorkspacePath } from "./egress-policy.js";
+import { isNotebook, notebookToDocument } from "./notebooks.js";
+import { splitLines } from "./positions.js";
+
+const LOCKFILES = new Set([
+  "package-lock.json",
+  "npm-shrinkwrap.json",
+  "yarn.lock",
+  "pnpm-lock.yaml",
+  "bun.lock",
+  "go.sum",
+  "Cargo.lock",
+  "poetry.lock",
+  "Pipfile.lock",
+  "uv.lock",
+  "Gemfile.lock",
+  "composer.lock",
+  "gradle.lockfile",
+]);
+
+const VENDOR_DIRS = /(^|\/)(vendor|node_modules|bower_components)\//;
+
+// Go's convention (https://go.dev/s/generatedcode), also used by protoc
+// plugins and mock generators in other languages
+const GENERATED_HEADER = /^\W*Code generated .* DO NOT EDIT\.?\s*(\*\/)?$/;
+
+// Markers are only looked for in the header comment, so code that checks
+// for them is not mistaken for generated code
+const HEADER_LINES = 20;
+
+const MINIFIED_MIN_SIZE = 1024;
+const MINIFIED_LINE_LENGTH = 500;
+const MINIFIED_MAX_WHITESPACE = 0.1;
+
+/**
+ * Check whether a file should be left out of analysis. Returns a
+ * human-readable reason if so, null otherwise.
+ */
+export function detectSkipReason(
+  filePath: string,
+  content: string,
+  rootDir: string,
+): string | null {
+  const relPath = toWorkspacePath(filePath, rootDir);
+  const name = basename(relPath);
+
+  if (LOCKFILES.has(name)) {
+    return "lockfile";
+  }
+  if (VENDOR_DIRS.test(relPath)) {
+    return "vendored dependency";
+  }
+
+  const header = splitLines(content.slice(0, 4096)).slice(0, HEADER_LINES);
+  if (header.some((line) => GENERATED_HEADER.test(line.trim()))) {
+    return 'generated code ("Code generated ... DO NOT EDIT." header)';
+  }
+  if (header.some((line) => /@generated\b/.test(line))) {
+    return "generated code (@generated marker)";
+  }
+
+  const code = isNotebook(name) ? notebookCode(content) : content;
+  if (/\.min\.(js|mjs|cjs|css)$/.test(name) || looksMinified(code)) {
+    return "minified code";
+  }
+
+  return null;
+}
+
+/**
+ * The code cells of a notebook. Outputs hold base64 images, long lines
+ * without whitespace that would look minified.
+ */
+function notebookCode(content: string): string {
+  try {
+    return notebookToDocument(content).content;
+  } catch {
+    return "";
+  }
+}
+
+/**
+ * Minified code is mostly very long lines with little whitespace.
+ */
+function looksMinified(content: string): boolean {
+  if (content.length < MINIFIED_MIN_SIZE) {
+    return false;
+  }
+
+  const longLines = splitLines(content).filter(
+    (line) => line.length > MINIFIED_LINE_LENGTH,
+  );
+  const longLength = longLines.reduce((sum, line) => sum + line.length, 0);
+  if (longLength < content.length / 2) {
+    return false;
+  }
+
+  const whitespace = longLines.reduce(
+    (sum, line) => sum + (line.match(/\s/g)?.length ?? 0),
+    0,
+  );
+  return whitespace / longLength < MINIFIED_MAX_WHITESPACE;
+}
@@ -0,0 +1,330 @@
+/**
+ * Triage of an existing lint backlog: diagnostics from other tools are grouped
+ * by rule, and the model ranks the groups, explains them and marks sampled
+ * diagnostics that are likely false positives.
+ *
+ * Only a few samples per group are sent, with short excerpts around them, so
+ * a backlog of thousands of items needs a handful of requests.
+ */
+
+import { existsSync, readFileSync } from "node:fs";
+import type { AilintConfig, ResolvedLLMConfig } from "../types/config.js";
+import {
+  buildTriageSystemPrompt,
+  buildTriageUserPrompt,
+} from "../llm/prompt-builder.js";
+import { sendLLMRequest } from "../llm/client.js";
+import { resolveAuditLogPath } from "../llm/audit-log.js";
+import { extractJSON } from "../utils/json-extract.js";
+import { logger } from "../utils/logger.js";
+import { checkEgressPolicy, toWorkspacePath } from "./egress-policy.js";
+import { getLanguageForFile } from "./languages.js";
+import type { Redactor } from "./redactor.js";
+import type { ExternalDiagnostic } from "./external-results.js";
+
+export type TriagePriority = "high" | "medium" | "low";
+
+const PRIORITIES: TriagePriority[] = ["high", "medium", "low"];
+
+const SAMPLES_PER_GROUP = 3;
+const GROUPS_PER_REQUEST = 10;
+const EXCERPT_CONTEXT_LINES = 3;
+
+export interface TriageGroup {
+  id: string; // G1, G2, ... in descending size
+  source: string;
+  rule?: string;
+  diagnostics: ExternalDiagnostic[];
+  samples: ExternalDiagnostic[];
+  // Set once the model has triaged the group
+  priority?: TriagePriority;
+  explanation?: string;
+  likelyFalsePositive?: boolean; // The rule is mostly noise in this codebase
+  falsePositives: Set<ExternalDiagnostic>; // Sampled diagnostics judged wrong
+}
+
+/**
+ * A sampled diagnostic as shown to the model.
+ */
+export interface TriageSample {
+  id: string; // G1.1, G1.2, ...
+  location: string;
+  message: string;
+  languageId?: string;
+  excerpt?: string; // Numbered lines, omitted if the file may not be sent
+}
+
+export interface TriagePromptGroup {
+  id: string;
+  source: string;
+  rule?: string;
+  count: number;
+  fileCount: number;
+  samples: TriageSample[];
+}
+
+export interface TriageOptions {
+  diagnostics: ExternalDiagnostic[];
+  rootDir: string;
+  config: AilintConfig;
+  llm: ResolvedLLMConfig;
+  redactor: Redactor | null;
+  maxGroups: number; // Largest groups sent to the model; the rest are listed
+  onBatch?: (done: number, total: number) => void;
+}
+
+export interface TriageResult {
+  groups: TriageGroup[];
+  triaged: number; // Groups the model answered for
+}
+
+/**
+ * Group diagnostics by tool and rule, largest group first. Diagnostics
+ * without a rule are grouped by their message with identifiers removed.
+ */
+export function groupForTriage(
+  diagnostics: ExternalDiagnostic[],
+): TriageGroup[] {
+  const byKey = new Map<string, ExternalDiagnostic[]>();
+  for (const diagnostic of diagnostics) {
+    const key = `${diagnostic.source}\0${diagnostic.rule ?? normalizeMessage(diagnostic.message)}`;
+    const list = byKey.get(key) ?? [];
+    list.push(diagnostic);
+    byKey.set(key, list);
+  }
+
+  return Array.from(byKey.values())
+    .sort((a, b) => b.length - a.length)
+    .map((list, index) => ({
+      id: `G${index + 1}`,
+      source: list[0].source,
+      rule: list[0].rule,
+      diagnostics: list,
+      samples: pickSamples(list),
+      falsePositives: new Set(),
+    }));
+}
+
+/**
+ * Send the largest groups to the model in batches and record its verdicts.
+ * A failed batch leaves its groups untriaged.
+ */
+export async function triageDiagnostics(
+  options: TriageOptions,
+): Promise<TriageResult> {
+  const { diagnostics, rootDir, config, llm, redactor, maxGroups } = options;
+  const groups = groupForTriage(diagnostics);
+  const selected = groups.slice(0, maxGroups);
+  const systemPrompt = buildTriageSystemPrompt();
+  const lineCache = new Map<string, string[] | null>();
+  let triaged = 0;
+
+  const batches: TriageGroup[][] = [];
+  for (let i = 0; i < selected.length; i += GROUPS_PER_REQUEST) {
+    batches.push(selected.slice(i, i + GROUPS_PER_REQUEST));
+  }
+
+  for (const [index, batch] of batches.entries()) {
+    const sampleIds = new Map<string, ExternalDiagnostic>();
+    const sentFiles = new Set<string>(); // Files with code in the prompt
+    const promptGroups = batch.map((group) => {
+      const samples = group.samples.map((diagnostic, i) => {
+        const id = `${group.id}.${i + 1}`;
+        sampleIds.set(id, diagnostic);
+        const sample = toSample(id, diagnostic, {
+          rootDir,
+          config,
+          llm,
+          redactor,
+          lineCache,
+        });
+        if (sample.excerpt) sentFiles.add(diagnostic.file);
+        return sample;
+      });
+      return {
+        id: group.id,
+        source: group.source,
+        rule: group.rule,
+        count: group.diagnostics.length,
+        fileCount: new Set(group.diagnostics.map((d) => d.file)).size,
+        samples,
+      };
+    });
+    const files = Array.from(sentFiles);
+
+    try {
+      const response = await sendLLMRequest({
+        systemPrompt,
+        userPrompt: buildTriageUserPrompt(promptGroups),
+        config: llm,
+        rateLimitPerMinute: config.performance.rateLimitPerMinute,
+        rateLimitEnabled: config.performance.rateLimitEnabled,
+        requestId: `triage-${index}`,
+        filePath: files[0] ?? "triage",
+        contextFiles: files.slice(1),
+        auditLogPath: resolveAuditLogPath(config.audit),
+      });
+
+      for (const verdict of parseTriageResponse(response.content)) {
+        const group = batch.find((g) => g.id === verdict.group);
+        if (!group) continue;
+        group.priority = verdict.priority;
+        group.explanation = verdict.explanation;
+        group.likelyFalsePositive = verdict.likelyFalsePositive;
+        for (const sampleId of verdict.falsePositives) {
+          const diagnostic = sampleIds.get(sampleId);
+          if (diagnostic) group.falsePositives.add(diagnostic);
+        }
+        triaged++;
+      }
+    } catch (error) {
+      logger.warn(
+        `Triage request ${index + 1}/${batches.length} failed: ${error instanceof Error ? error.message : error}`,
+      );
+    }
+
+    options.onBatch?.(index + 1, batches.length);
+  }
+
+  return { groups, triaged };
+}
+
+export interface TriageVerdict {
+  group: string;
+  priority: TriagePriority;
+  explanation?: string;
+  likelyFalsePositive: boolean;
+  falsePositives: string[];
+}
+
+/**
+ * Parse the model's JSON answer, skipping malformed entries.
+ */
+export function parseTriageResponse(response: string): TriageVerdict[] {
+  const extracted = extractJSON(response);
+  if (!Array.isArray(extracted)) {
+    logger.warn("Triage response is not a JSON array");
+    return [];
+  }
+
+  const verdicts: TriageVerdict[] = [];
+  for (const item of extracted) {
+    if (typeof item !== "object" || item === null) continue;
+    const raw = item as Record<string, unknown>;
+    const priority =
+      typeof raw["priority"] === "string"
+        ? (raw["priority"].toLowerCase() as TriagePriority)
+        : undefined;
+    if (typeof raw["group"] !== "string" || !priority) continue;
+    if (!PRIORITIES.includes(priority)) continue;
+
+    verdicts.push({
+      group: raw["group"],
+      priority,
+      explanation:
+        typeof raw["explanation"] === "string" ? raw["explanation"] : undefined,
+      likelyFalsePositive: raw["likelyFalsePositive"] === true,
+      falsePositives: Array.isArray(raw["falsePositives"])
+        ? raw["falsePositives"].filter(
+            (id): id is string => typeof id === "string",
+          )
+        : [],
+    });
+  }
+  return verdicts;
+}
+
+/**
+ * Sort groups for reporting: by priority, then size. Untriaged groups last.
+ */
+export function sortTriageGroups(groups: TriageGroup[]): TriageGroup[] {
+  const rank = (g: TriageGroup) =>
+    g.priority ? PRIORITIES.indexOf(g.priority) : PRIORITIES.length;
+  return [...groups].sort(
+    (a, b) => rank(a) - rank(b) || b.diagnostics.length - a.diagnostics.length,
+  );
+}
+
+/**
+ * Spread samples over different files so one file does not dominate.
+ */
+function pickSamples(diagnostics: ExternalDiagnostic[]): ExternalDiagnostic[] {
+  const samples: ExternalDiagnostic[] = [];
+  const seenFiles = new Set<string>();
+
+  for (const diagnostic of diagnostics) {
+    if (samples.length >= SAMPLES_PER_GROUP) break;
+    if (seenFiles.has(diagnostic.file)) continue;
+    seenFiles.add(diagnostic.file);
+    samples.push(diagnostic);
+  }
+  for (const diagnostic of diagnostics) {
+    if (samples.length >= SAMPLES_PER_GROUP) break;
+    if (!samples.includes(diagnostic)) samples.push(diagnostic);
+  }
+
+  return samples;
+}
+
+function toSample(
+  id: string,
+  diagnostic: ExternalDiagnostic,
+  context: {
+    rootDir: string;
+    config: AilintConfig;
+    llm: ResolvedLLMConfig;
+    redactor: Redactor | null;
+    lineCache: Map<string, string[] | null>;
+  },
+): TriageSample {
+  const { rootDir, config, llm, redactor, lineCache } = context;
+  const relPath = toWorkspacePath(diagnostic.file, rootDir);
+  const redact = (text: string) => redactor?.redact(text).text ?? text;
+  const sample: TriageSample = {
+    id,
+    location: `${relPath}:${diagnostic.line}`,
+    message: redact(diagnostic.message),
+    languageId: getLanguageForFile(diagnostic.file)?.id,
+  };
+
+  if (checkEgressPolicy(diagnostic.file, llm, config.privacy, rootDir)) {
+    return sample;
+  }
+
+  // The whole file is redacted before excerpts are cut from it, so secrets
+  // spanning lines, like PEM blocks, are found
+  if (!lineCache.has(diagnostic.file)) {
+    lineCache.set(
+      diagnostic.file,
+      existsSync(diagnostic.file)
+        ? redact(readFileSync(diagnostic.file, "utf-8")).split("\n")
+        : null,
+    );
+  }
+  const lines = lineCache.get(diagnostic.file);
+  if (!lines) {
+    return sample;
+  }
+
+  const start = Math.max(1, diagnostic.line - EXCERPT_CONTEXT_LINES);
+  const end = Math.min(
+    lines.length,
+    (diagnostic.endLine ?? diagnostic.line) + EXCERPT_CONTEXT_LINES,
+  );
+  sample.excerpt = lines
+    .slice(start - 1, end)
+    .map((line, i) => `${start + i}: ${line}`)
+    .join("\n");
+  return sample;
+}
+
+/**
+ * Strip identifiers, numbers and quoted names so messages of one kind group
+ * together, e.g. "`f.Close` is not checked" and "`w.Write` is not checked".
+ */
+function normalizeMessage(message: string): string {
+  return message
+    .replace(/`[^`]*`|"[^"]*"|'[^']*'/g, "_")
+    .replace(/\d+/g, "N")
+    .trim();
+}
@@ -0,0 +1,99 @@
+/**
+ * Group files into analysis units for "package" mode.
+ *
+ * A unit is the files of one directory and language, which is a Go package or
+ * a TS/JS module directory. Units larger than the token budget are split into
+ * several requests.
+ */
+
+import { dirname, extname } from "node:path";
+import { getLanguageForFile } from "./languages.js";
+
+/**
+ * Where a file to group comes from: its work tree path, and for a version
+ * read from git history, the commit.
+ */
+export interface UnitSource {
+  path: string;
+  commit?: string;
+}
+
+/**
+ * Group files by directory and language, keeping each group within maxTokens.
+ * tokensOf returns the estimated prompt size of a file. Files named other
+ * than by their path, like versions from a commit range, are grouped by the
+ * source sourceOf returns, so versions from different commits are never in
+ * one unit.
+ */
+export function groupIntoUnits(
+  files: string[],
+  maxTokens: number,
+  tokensOf: (filePath: string) => number,
+  sourceOf: (filePath: string) => UnitSource = (path) => ({ path }),
+): string[][] {
+  const groups = new Map<string, string[]>();
+
+  for (const filePath of files) {
+    const { path, commit = "" } = sourceOf(filePath);
+    const language = getLanguageForFile(path);
+    const key = [commit, dirname(path), language?.id ?? extname(path)].join(
+      "\0",
+    );
+    const group = groups.get(key) ?? [];
+    group.push(filePath);
+    groups.set(key, group);
+  }
+
+  const units: string[][] = [];
+  for (const group of groups.values()) {
+    let current: string[] = [];
+    let currentTokens = 0;
+
+    for (const filePath of [...group].sort()) {
+      const tokens = tokensOf(filePath);
+      if (current.length > 0 && currentTokens + tokens > maxTokens) {
+        units.push(current);
+        current = [];
+        currentTokens = 0;
+      }
+      current.push(filePath);
+      currentTokens += tokens;
+    }
+
+    if (current.length > 0) {
+      units.push(current);
+    }
+  }
+
+  return units;
+}
+
+/**
+ * Find which unit file a finding's "file" field refers to. Models sometimes
+ * shorten paths, so a unique suffix or basename match is accepted too.
+ */
+export function matchUnitFile(
+  reported: string | undefined,
+  files: string[],
+): string | undefined {
+  if (!reported) {
+    return files.length === 1 ? files[0] : undefined;
+  }
+
+  const normalized = reported.replace(/\\/g, "/").replace(/^\.\//, "");
+  const exact = files.find((f) => f === normalized);
+  if (exact) {
+    return exact;
+  }
+
+  const suffix = files.filter(
+    (f) => f.endsWith(`/${normalized}`) || normalized.endsWith(`/${f}`),
+  );
+  if (suffix.length === 1) {
+    return suffix[0];
+  }
+
+  const base = normalized.slice(normalized.lastIndexOf("/") + 1);
+  const byName = files.filter((f) => f.slice(f.lastIndexOf("/") + 1) === base);
+  return byName.length === 1 ? byName[0] : undefined;
+}
@@ -0,0 +1,212 @@
+/**
+ * Verifier pass: sends each finding with its code excerpt to a second prompt,
+ * possibly on a different model, which confirms, adjusts or drops it.
+ *
+ * Verification fails open: if the verifier errors or answers with something
+ * unparseable, the finding is kept unchanged.
+ */
+
+import type { Finding, FindingSeverity } from "../types/finding.js";
+import { FindingSeveritySchema } from "../types/finding.js";
+import type { AilintConfig, ResolvedLLMConfig } from "../types/config.js";
+import {
+  buildVerifierSystemPrompt,
+  buildVerifierUserPrompt,
+} from "../llm/prompt-builder.js";
+import { sendLLMRequest } from "../llm/client.js";
+import { resolveAuditLogPath } from "../llm/audit-log.js";
+import { extractJSON } from "../utils/json-extract.js";
+import { logger } from "../utils/logger.js";
+import { checkEgressPolicy } from "./egress-policy.js";
+
+const EXCERPT_CONTEXT_LINES = 10;
+const MAX_EXCERPT_LINES = 200;
+
+const SEVERITY_RANK: Record<FindingSeverity, number> = {
+  error: 3,
+  warning: 2,
+  info: 1,
+  hint: 0,
+};
+
+export interface Verdict {
+  valid: boolean;
+  severity?: FindingSeverity;
+  confidence?: number;
+  reason?: string;
+}
+
+export interface VerifyOptions {
+  filePath: string;
+  content: string; // Content as sent to the analysis model (redacted)
+  findings: Finding[];
+  languageId?: string;
+  rootDir: string;
+  config: AilintConfig;
+  llm: ResolvedLLMConfig; // Verifier endpoint
+}
+
+export interface VerifyResult {
+  findings: Finding[];
+  verified: number;
+  dropped: number;
+}
+
+/**
+ * Verify findings at or above the configured minimum severity.
+ */
+export async function verifyFindings(
+  options: VerifyOptions,
+): Promise<VerifyResult> {
+  const { filePath, content, findings, languageId, rootDir, config, llm } =
+    options;
+  const minRank = SEVERITY_RANK[config.analysis.verify.minSeverity];
+
+  const violation = checkEgressPolicy(filePath, llm, config.privacy, rootDir);
+  if (violation) {
+    logger.warn(`Skipping verification: ${violation}`);
+    return { findings, verified: 0, dropped: 0 };
+  }
+
+  const systemPrompt = buildVerifierSystemPrompt(languageId);
+  const lines = content.split("\n");
+  const kept: Finding[] = [];
+  let verified = 0;
+  let dropped = 0;
+
+  for (const [index, finding] of findings.entries()) {
+    if (SEVERITY_RANK[finding.severity] < minRank) {
+      kept.push(finding);
+      continue;
+    }
+
+    let verdict: Verdict | null;
+    try {
+      const response = await sendLLMRequest({
+        systemPrompt,
+        userPrompt: buildVerifierUserPrompt(
+          filePath,
+          buildExcerpt(lines, finding),
+          finding,
+          languageId,
+        ),
+        config: llm,
+        rateLimitPerMinute: config.performance.rateLimitPerMinute,
+        rateLimitEnabled: config.performance.rateLimitEnabled,
+        requestId: `${filePath}#verify-${index}`,
+        filePath,
+        auditLogPath: resolveAuditLogPath(config.audit),
+      });
+      verdict = parseVerdict(response.content);
+    } catch (error) {
+      logger.warn(
+        `Verifier request failed for ${finding.id}, keeping finding: ${error instanceof Error ? error.message : error}`,
+      );
+      kept.push(finding);
+      continue;
+    }
+
+    if (!verdict) {
+      logger.debug(`Unparseable verdict for ${finding.id}, keeping finding`);
+      kept.push(finding);
+      continue;
+    }
+
+    verified++;
+    const adjusted = applyVerdict(finding, verdict);
+    if (adjusted) {
+      kept.push(adjusted);
+    } else {
+      dropped++;
+      logger.debug(
+        `Verifier dropped ${finding.id} "${finding.title}": ${verdict.reason ?? "no reason given"}`,
+      );
+    }
+  }
+
+  return { findings: kept, verified, dropped };
+}
+
+/**
+ * Apply a verdict to a finding. Returns null if the finding is rejected.
+ * The verifier may lower severity but never raise it.
+ */
+export function applyVerdict(
+  finding: Finding,
+  verdict: Verdict,
+): Finding | null {
+  if (!verdict.valid) {
+    return null;
+  }
+
+  const severity =
+    verdict.severity &&
+    SEVERITY_RANK[verdict.severity] < SEVERITY_RANK[finding.severity]
+      ? verdict.severity
+      : finding.severity;
+  const confidence =
+    verdict.confidence !== undefined
+      ? Math.min(finding.confidence, verdict.confidence)
+      : finding.confidence;
+
+  return { ...finding, severity, confidence };
+}
+
+/**
+ * Parse the verifier's JSON answer.
+ */
+export function parseVerdict(response: string): Verdict | null {
+  const extracted = extractJSON(response);
+  const obj = Array.isArray(extracted) ? extracted[0] : extracted;
+  if (typeof obj !== "object" || obj === null) {
+    return null;
+  }
+
+  const raw = obj as Record<string, unknown>;
+  let valid: boolean;
+  if (typeof raw["valid"] === "boolean") {
+    valid = raw["valid"];
+  } else if (typeof raw["valid"] === "string") {
+    valid = /^(?:true|yes)$/i.test(raw["valid"]);
+  } else {
+    return null;
+  }
+
+  const severity = FindingSeveritySchema.safeParse(
+    typeof raw["severity"] === "string" ? raw["severity"].toLowerCase() : "",
+  );
+
+  return {
+    valid,
+    severity: severity.success ? severity.data : undefined,
+    confidence:
+      typeof raw["confidence"] === "number"
+        ? Math.max(0, Math.min(1, raw["confidence"]))
+        : undefined,
+    reason: typeof raw["reason"] === "string" ? raw["reason"] : undefined,
+  };
+}
+
+/**
+ * Lines around the finding numbered from 1, or the start of the file if it
+ * has no range.
+ */
+export function buildExcerpt(lines: string[], finding: Finding): string {
+  let start = 1;
+  let end = Math.min(lines.length, MAX_EXCERPT_LINES);
+
+  if (finding.range) {
+    // Ranges count lines from 0
+    start = Math.max(1, finding.range.startLine + 1 - EXCERPT_CONTEXT_LINES);
+    end = Math.min(
+      lines.length,
+      finding.range.endLine + 1 + EXCERPT_CONTEXT_LINES,
+      start + MAX_EXCERPT_LINES - 1,
+    );
+  }
+
+  return lines
+    .slice(start - 1, end)
+    .map((line, i) => `${start + i}: ${line}`)
+    .join("\n");
+}
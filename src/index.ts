@@ -1,8 +1,21 @@
 import { Command } from "commander";
 import { runCLI, type CLIArgs } from "./cli/index.js";
+import { runAudit } from "./cli/audit.js";
+import { runIndex } from "./cli/build-index.js";
+import { runTriage } from "./cli/triage.js";
+import { runMerge } from "./cli/merge.js";
+import { parseShard, type Shard } from "./cli/shard.js";
+import { parseDuration } from "./cli/budget.js";
+import { parseIntegerOption } from "./cli/options.js";
 import { startLSPServer } from "./lsp/server.js";
 import { loadConfig, validateAPIKey } from "./config/loader.js";
-import type { LLMProvider } from "./types/config.js";
+import {
+  FileOrderSchema,
+  OutputFormatSchema,
+  type FileOrder,
+  type LLMProvider,
+  type OutputFormat,
+} from "./types/config.js";
 import { ENV_VAR_MAPPINGS } from "./config/defaults.js";
 
 const COLORS = {
@@ -97,21 +110,86 @@ program
   .argument("[paths...]", "Files or directories to analyze")
   .option("--lsp", "Start as Language Server Protocol server")
   .option("--init", "Create lintai.json config file")
-  .option("--json", "Output results as JSON")
+  .option("--json", "Output results as JSON (same as --format json)")
+  .option(
+    "--format <format>",
+    "Output format (human, json, markdown, sarif; default: cli.format)",
+  )
   .option("--debug", "Enable debug logging")
   .option("-c, --config <path>", "Path to config file")
   .option(
     "--ext <extensions>",
-    "File extensions to analyze (comma-separated)",
-    "ts,tsx",
+    "File extensions to analyze, comma-separated (default: cli.extensions)",
+  )
+  .option(
+    "--include <glob>",
+    "Also analyze files matching this glob, e.g. Dockerfile (repeatable)",
+    (value: string, previous: string[]) => [...previous, value],
+    [] as string[],
+  )
+  .option(
+    "--max-files <number>",
+    "Maximum number of files to analyze (default: cli.maxFiles, 100)",
   )
-  .option("--max-files <number>", "Maximum number of files to analyze", "100")
   .option("--model <model>", "LLM model to use")
   .option("--base-url <url>", "LLM API base URL")
   .option(
     "--provider <provider>",
     "LLM provider (openai, anthropic, gemini, ollama, openai-compatible)",
   )
+  .option(
+    "--with-results <file>",
+    "SARIF, eslint or golangci-lint JSON, or tsc output to treat as already reported (repeatable)",
+    (value: string, previous: string[]) => [...previous, value],
+    [] as string[],
+  )
+  .option(
+    "--coverage <file>",
+    "Go cover profile, lcov or Cobertura report (repeatable)",
+    (value: string, previous: string[]) => [...previous, value],
+    [] as string[],
+  )
+  .option(
+    "--order <strategy>",
+    "Files to analyze first when --max-files truncates (churn, recent, size, random-seeded)",
+  )
+  .option("--seed <number>", "Seed for --order random-seeded")
+  .option("--blame", "Annotate findings with git blame author and age")
+  .option(
+    "--max-age <days>",
+    "Only report findings on lines changed in the last N days (uses git blame)",
+  )
+  .option("--group-by <key>", "Group the report by CODEOWNERS owner (owner)")
+  .option(
+    "--owner <owner>",
+    "Only analyze files owned by this CODEOWNERS owner, e.g. @org/team (repeatable)",
+    (value: string, previous: string[]) => [...previous, value],
+    [] as string[],
+  )
+  .option(
+    "--shard <i/n>",
+    "Analyze only shard i of n, for parallel CI jobs (combine with lintai merge)",
+  )
+  .option(
+    "--resume",
+    "Continue an interrupted run, reusing the results of files already analyzed",
+  )
+  .option(
+    "--time-budget <duration>",
+    "Stop analyzing after this long, e.g. 10m or 1h30m (exit code 3)",
+  )
+  .option(
+    "--max-requests <number>",
+    "Stop analyzing after this many LLM requests (exit code 3)",
+  )
+  .option(
+    "--rev <commit>",
+    "Analyze files as of a commit, read from git instead of the working tree",
+  )
+  .option(
+    "--range <A..B>",
+    "Analyze every file version added or modified by the commits in A..B",
+  )
   .action(async (paths: string[], options) => {
     // --init doesn't need API key
     if (options.init) {
@@ -126,6 +204,90 @@ program
       process.exit(exitCode);
     }
 
+    if (options.order && !FileOrderSchema.safeParse(options.order).success) {
+      console.error(
+        `Error: Unknown --order "${options.order}" (use ${FileOrderSchema.options.join(", ")})`,
+      );
+      process.exit(2);
+    }
+
+    if (
+      options.format &&
+      !OutputFormatSchema.safeParse(options.format).success
+    ) {
+      console.error(
+        `Error: Unknown --format "${options.format}" (use ${OutputFormatSchema.options.join(", ")})`,
+      );
+      process.exit(2);
+    }
+
+    if (options.groupBy && options.groupBy !== "owner") {
+      console.error(
+        `Error: Unknown --group-by "${options.groupBy}" (use owner)`,
+      );
+      process.exit(2);
+    }
+
+    if (options.rev && options.range) {
+      console.error("Error: Use either --rev or --range, not both");
+      process.exit(2);
+    }
+
+    if (options.range && !options.range.includes("..")) {
+      console.error(
+        `Error: Invalid --range "${options.range}", expected A..B (e.g. main..HEAD)`,
+      );
+      process.exit(2);
+    }
+
+    let shard: Shard | undefined;
+    if (options.shard) {
+      try {
+        shard = parseShard(options.shard);
+      } catch (error) {
+        console.error(
+          `Error: ${error instanceof Error ? error.message : error}`,
+        );
+        process.exit(2);
+      }
+    }
+
+    let timeBudget: number | undefined;
+    let maxRequests: number | undefined;
+    try {
+      if (options.timeBudget) {
+        timeBudget = parseDuration(options.timeBudget);
+      }
+      if (options.maxRequests !== undefined) {
+        maxRequests = parseIntegerOption(
+          options.maxRequests,
+          "--max-requests",
+          true,
+        );
+      }
+    } catch (error) {
+      console.error(
+        `Error: ${error instanceof Error ? error.message : error}`,
+      );
+      process.exit(2);
+    }
+
+    let seed: number | undefined;
+    let maxAge: number | undefined;
+    try {
+      if (options.seed !== undefined) {
+        seed = parseIntegerOption(options.seed, "--seed");
+      }
+      if (options.maxAge !== undefined) {
+        maxAge = parseIntegerOption(options.maxAge, "--max-age");
+      }
+    } catch (error) {
+      console.error(
+        `Error: ${error instanceof Error ? error.message : error}`,
+      );
+      process.exit(2);
+    }
+
     // Check API key before starting LSP or analysis
     if (!checkAPIKey(options)) {
       process.exit(2);
@@ -143,17 +305,144 @@ program
       lsp: false,
       init: false,
       json: options.json ?? false,
+      format: options.format as OutputFormat | undefined,
       debug: options.debug ?? false,
       config: options.config,
       ext: options.ext,
-      maxFiles: parseInt(options.maxFiles, 10),
+      include: options.include,
+      maxFiles:
+        options.maxFiles !== undefined
+          ? parseInt(options.maxFiles, 10)
+          : undefined,
       model: options.model,
       baseUrl: options.baseUrl,
       provider: options.provider as LLMProvider | undefined,
+      withResults: options.withResults,
+      coverage: options.coverage,
+      order: options.order as FileOrder | undefined,
+      seed,
+      blame: options.blame,
+      maxAge,
+      groupBy: options.groupBy,
+      owner: options.owner,
+      shard,
+      resume: options.resume ?? false,
+      timeBudget,
+      maxRequests,
+      rev: options.rev,
+      range: options.range,
     };
 
     const exitCode = await runCLI(args);
     process.exit(exitCode);
   });
 
+program
+  .command("audit")
+  .description("Query the audit log of content sent to LLM providers")
+  .option("-c, --config <path>", "Path to config file")
+  .option("--log <path>", "Path to audit log (default: from config)")
+  .option("--since <date>", "Only entries at or after this date")
+  .option("--until <date>", "Only entries at or before this date")
+  .option("--provider <provider>", "Only entries for this provider")
+  .option("--file <glob>", "Only entries for files matching this glob")
+  .option("--remote-only", "Only requests to endpoints off this machine")
+  .option("--files", "List each file that was sent instead of each request")
+  .option("--json", "Output as JSON")
+  .action((options) => {
+    const exitCode = runAudit({
+      config: options.config,
+      log: options.log,
+      since: options.since,
+      until: options.until,
+      provider: options.provider,
+      file: options.file,
+      remoteOnly: options.remoteOnly ?? false,
+      files: options.files ?? false,
+      json: options.json ?? false,
+    });
+    process.exit(exitCode);
+  });
+
+program
+  .command("index")
+  .description("Build the local embedding index used for retrieval")
+  .argument("[paths...]", "Files or directories to index")
+  .option("-c, --config <path>", "Path to config file")
+  .option("--rebuild", "Re-embed every chunk instead of reusing the index")
+  .option("--debug", "Enable debug logging")
+  .action(async (paths: string[], options) => {
+    const exitCode = await runIndex({
+      paths: paths.length > 0 ? paths : ["."],
+      config: options.config,
+      debug: options.debug ?? false,
+      rebuild: options.rebuild ?? false,
+    });
+    process.exit(exitCode);
+  });
+
+program
+  .command("triage")
+  .description("Group, prioritize and explain results from other linters")
+  .argument(
+    "<results...>",
+    "SARIF, eslint or golangci-lint JSON, or tsc output",
+  )
+  .option("-c, --config <path>", "Path to config file")
+  .option("-o, --output <path>", "Write annotated SARIF to this file")
+  .option("--max-groups <number>", "Largest rule groups to triage", "30")
+  .option(
+    "--top <number>",
+    "Groups to show with explanation and samples",
+    "10",
+  )
+  .option("--json", "Output as JSON")
+  .option("--debug", "Enable debug logging")
+  .action(async (results: string[], options) => {
+    let maxGroups: number;
+    let top: number;
+    try {
+      maxGroups = parseIntegerOption(options.maxGroups, "--max-groups", true);
+      top = parseIntegerOption(options.top, "--top", true);
+    } catch (error) {
+      console.error(
+        `Error: ${error instanceof Error ? error.message : error}`,
+      );
+      process.exit(2);
+    }
+
+    const exitCode = await runTriage({
+      results,
+      config: options.config,
+      debug: options.debug ?? false,
+      json: options.json ?? false,
+      output: options.output,
+      maxGroups,
+      top,
+    });
+    process.exit(exitCode);
+  });
+
+program
+  .command("merge")
+  .description("Combine JSON reports from --shard runs into one report")
+  .argument("<reports...>", "JSON reports written with --json")
+  .option("-o, --output <path>", "Write the merged report to this file")
+  .option("--format <format>", "Output format (json, markdown, sarif)", "json")
+  .action((reports: string[], options) => {
+    if (!["json", "markdown", "sarif"].includes(options.format)) {
+      console.error(
+        `Error: Unknown --format "${options.format}" (use json, markdown, sarif)`,
+      );
+      process.exit(2);
+    }
+
+    const exitCode = runMerge({
+      inputs: reports,
+      output: options.output,
+      format: options.format,
+    });
+    process.exit(exitCode);
+  });
+
 program.parse();
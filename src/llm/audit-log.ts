@@ -0,0 +1,180 @@
+import {
+  appendFileSync,
+  existsSync,
+  mkdirSync,
+  readFileSync,
+} from "node:fs";
+import { dirname, join } from "node:path";
+import { createHash, randomUUID } from "node:crypto";
+import { homedir, hostname, userInfo } from "node:os";
+import type {
+  AuditConfig,
+  LLMProvider,
+  ResolvedLLMConfig,
+} from "../types/config.js";
+import { isLocalProvider } from "../core/egress-policy.js";
+import { logger } from "../utils/logger.js";
+
+/**
+ * One outbound LLM request, as recorded in the audit log.
+ * A request is written with status "sent" before it goes out and again,
+ * under the same id, once it completes; "sent" alone means it never did.
+ */
+export interface AuditEntry {
+  id?: string; // Missing in logs written before requests were pre-recorded
+  timestamp: string;
+  provider: LLMProvider;
+  model: string;
+  endpoint: string;
+  remote: boolean;
+  filePath?: string;
+  contextFiles?: string[]; // Other files included, e.g. by tool calls
+  contentHash: string;
+  bytes: number;
+  promptTokens?: number;
+  completionTokens?: number;
+  status: "sent" | "ok" | "error";
+  user: string;
+  host: string;
+  pid: number;
+  command: string;
+}
+
+export interface AuditRecordOptions {
+  config: ResolvedLLMConfig;
+  content: string;
+  filePath?: string;
+  contextFiles?: string[];
+}
+
+export interface AuditOutcome {
+  usage?: { promptTokens: number; completionTokens: number };
+  status: "ok" | "error";
+}
+
+/**
+ * Default audit log location, following the XDG state directory convention.
+ */
+export function getDefaultAuditLogPath(): string {
+  const stateHome =
+    process.env["XDG_STATE_HOME"] || join(homedir(), ".local", "state");
+  return join(stateHome, "lintai", "audit.jsonl");
+}
+
+/**
+ * Get the audit log path for a config, or undefined if auditing is disabled.
+ */
+export function resolveAuditLogPath(config: AuditConfig): string | undefined {
+  if (!config.enabled) {
+    return undefined;
+  }
+  return config.path ?? getDefaultAuditLogPath();
+}
+
+/**
+ * Append an entry for an outbound request to the audit log before it is
+ * sent, so that it is logged even if the process dies waiting for the
+ * answer. Call the returned function with the outcome to append the
+ * completed entry. The log is JSON Lines and only ever appended to.
+ */
+export function beginAuditEntry(
+  logPath: string,
+  options: AuditRecordOptions,
+): (outcome: AuditOutcome) => void {
+  const entry = createAuditEntry(options);
+  appendAuditEntry(logPath, entry);
+
+  return ({ usage, status }) => {
+    appendAuditEntry(logPath, {
+      ...entry,
+      promptTokens: usage?.promptTokens,
+      completionTokens: usage?.completionTokens,
+      status,
+    });
+  };
+}
+
+function createAuditEntry(options: AuditRecordOptions): AuditEntry {
+  const { config, content, filePath, contextFiles } = options;
+
+  let endpoint = config.baseUrl;
+  try {
+    endpoint = new URL(config.baseUrl).host;
+  } catch {
+    // Keep the raw base URL
+  }
+
+  return {
+    id: randomUUID(),
+    timestamp: new Date().toISOString(),
+    provider: config.provider,
+    model: config.model,
+    endpoint,
+    remote: !isLocalProvider(config),
+    filePath,
+    contextFiles,
+    contentHash: createHash("sha256").update(content).digest("hex"),
+    bytes: Buffer.byteLength(content, "utf-8"),
+    status: "sent",
+    user: getUserName(),
+    host: hostname(),
+    pid: process.pid,
+    command: process.argv.slice(1).join(" "),
+  };
+}
+
+function appendAuditEntry(logPath: string, entry: AuditEntry): void {
+  try {
+    mkdirSync(dirname(logPath), { recursive: true });
+    appendFileSync(logPath, JSON.stringify(entry) + "\n", {
+      encoding: "utf-8",
+      mode: 0o600,
+      flag: "a",
+    });
+  } catch (error) {
+    logger.error(`Failed to write audit log ${logPath}:`, error);
+  }
+}
+
+/**
+ * Read all entries from an audit log. Malformed lines are skipped, and a
+ * completed entry replaces the "sent" entry with the same id.
+ */
+export function readAuditLog(logPath: string): AuditEntry[] {
+  if (!existsSync(logPath)) {
+    return [];
+  }
+
+  const entries: AuditEntry[] = [];
+  const positions = new Map<string, number>();
+  const lines = readFileSync(logPath, "utf-8").split("\n");
+
+  for (const [index, line] of lines.entries()) {
+    if (!line.trim()) continue;
+    let entry: AuditEntry;
+    try {
+      entry = JSON.parse(line) as AuditEntry;
+    } catch {
+      logger.warn(`Skipping malformed audit log line ${index + 1}`);
+      continue;
+    }
+
+    const position = entry.id ? positions.get(entry.id) : undefined;
+    if (position !== undefined) {
+      entries[position] = entry;
+    } else {
+      if (entry.id) positions.set(entry.id, entries.length);
+      entries.push(entry);
+    }
+  }
+
+  return entries;
+}
+
+function getUserName(): string {
+  try {
+    return userInfo().username;
+  } catch {
+    return process.env["USER"] || process.env["USERNAME"] || "unknown";
+  }
+}
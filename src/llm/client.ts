@@ -2,6 +2,7 @@ import type { ResolvedLLMConfig, LLMProvider } from "../types/config.js";
 import { logger } from "../utils/logger.js";
 import { getGlobalRateLimiter } from "./rate-limiter.js";
 import { getGlobalRequestQueue } from "./request-queue.js";
+import { beginAuditEntry, type AuditOutcome } from "./audit-log.js";
 
 export interface LLMResponse {
   content: string;
@@ -21,6 +22,9 @@ export interface LLMRequestOptions {
   rateLimitEnabled?: boolean;
   requestId?: string; // For queue deduplication (e.g., file path)
   signal?: AbortSignal; // For cancellation
+  filePath?: string; // Source file the prompt was built from (for auditing)
+  contextFiles?: string[]; // Other files included in the prompt (for auditing)
+  auditLogPath?: string; // Record the request in this audit log if set
 }
 
 // ============================================================================
@@ -68,7 +72,7 @@ async function requestOpenAI(options: LLMRequestOptions): Promise<LLMResponse> {
     method: "POST",
     headers,
     body: JSON.stringify(body),
-    signal: AbortSignal.timeout(config.timeout),
+    signal: requestSignal(config.timeout, options.signal),
   });
 
   if (!response.ok) {
@@ -137,7 +141,7 @@ async function requestAnthropic(
     method: "POST",
     headers,
     body: JSON.stringify(body),
-    signal: AbortSignal.timeout(config.timeout),
+    signal: requestSignal(config.timeout, options.signal),
   });
 
   if (!response.ok) {
@@ -215,7 +219,7 @@ async function requestGemini(options: LLMRequestOptions): Promise<LLMResponse> {
       "Content-Type": "application/json",
     },
     body: JSON.stringify(body),
-    signal: AbortSignal.timeout(config.timeout),
+    signal: requestSignal(config.timeout, options.signal),
   });
 
   if (!response.ok) {
@@ -279,7 +283,7 @@ async function requestOllama(options: LLMRequestOptions): Promise<LLMResponse> {
       "Content-Type": "application/json",
     },
     body: JSON.stringify(body),
-    signal: AbortSignal.timeout(config.timeout),
+    signal: requestSignal(config.timeout, options.signal),
   });
 
   if (!response.ok) {
@@ -332,6 +336,306 @@ export class LLMError extends Error {
   }
 }
 
+/**
+ * A request refused because the request budget is used up.
+ */
+export class RequestBudgetError extends Error {
+  constructor(limit: number) {
+    super(`Request budget of ${limit} used up`);
+    this.name = "RequestBudgetError";
+  }
+}
+
+// ============================================================================
+// Tool Calling (OpenAI / Anthropic)
+// ============================================================================
+
+export interface ToolDefinition {
+  name: string;
+  description: string;
+  parameters: Record<string, unknown>; // JSON Schema for the arguments
+}
+
+export interface ToolCall {
+  id: string;
+  name: string;
+  input: Record<string, unknown>;
+}
+
+export interface ToolResult {
+  content: string;
+  files?: string[]; // Files whose content is included (for auditing)
+}
+
+export type ToolExecutor = (call: ToolCall) => Promise<ToolResult>;
+
+export interface ToolLoopOptions {
+  tools: ToolDefinition[];
+  execute: ToolExecutor;
+  maxCalls: number;
+}
+
+interface ToolTurnResponse extends LLMResponse {
+  toolCalls: ToolCall[];
+}
+
+/**
+ * A provider-specific multi-turn conversation with tool definitions.
+ */
+interface ToolConversation {
+  send(allowTools: boolean): Promise<ToolTurnResponse>;
+  addToolResults(results: Array<{ id: string; content: string }>): void;
+  transcript(): string;
+}
+
+interface OpenAIToolResponse {
+  choices?: Array<{
+    message?: {
+      content?: string | null;
+      tool_calls?: Array<{
+        id: string;
+        type: string;
+        function: { name: string; arguments: string };
+      }>;
+    };
+  }>;
+  usage?: OpenAIResponse["usage"];
+}
+
+function createOpenAIConversation(
+  options: LLMRequestOptions,
+  tools: ToolDefinition[],
+): ToolConversation {
+  const { config } = options;
+  const messages: Array<Record<string, unknown>> = [
+    { role: "system", content: options.systemPrompt },
+    { role: "user", content: options.userPrompt },
+  ];
+  let lastMessage: Record<string, unknown> | null = null;
+
+  return {
+    async send(allowTools) {
+      const url = `${config.baseUrl}/chat/completions`;
+      const body = {
+        model: config.model,
+        messages,
+        max_tokens: config.maxTokens,
+        temperature: 0.1,
+        tools: tools.map((tool) => ({
+          type: "function",
+          function: {
+            name: tool.name,
+            description: tool.description,
+            parameters: tool.parameters,
+          },
+        })),
+        tool_choice: allowTools ? "auto" : "none",
+      };
+
+      const headers: Record<string, string> = {
+        "Content-Type": "application/json",
+      };
+      if (config.apiKey) {
+        headers["Authorization"] = `Bearer ${config.apiKey}`;
+      }
+
+      logger.debug(`Sending tool request to OpenAI at ${url}`);
+
+      const response = await fetch(url, {
+        method: "POST",
+        headers,
+        body: JSON.stringify(body),
+        signal: requestSignal(config.timeout, options.signal),
+      });
+
+      if (!response.ok) {
+        const errorText = await response.text().catch(() => "Unknown error");
+        throw new LLMError(
+          `OpenAI API error: ${response.status} ${response.statusText}`,
+          response.status,
+          errorText,
+        );
+      }
+
+      const data = (await response.json()) as OpenAIToolResponse;
+      const message = data.choices?.[0]?.message;
+      lastMessage = {
+        role: "assistant",
+        content: message?.content ?? null,
+        ...(message?.tool_calls && { tool_calls: message.tool_calls }),
+      };
+
+      return {
+        content: message?.content ?? "",
+        toolCalls: (message?.tool_calls ?? []).map((call) => ({
+          id: call.id,
+          name: call.function.name,
+          input: parseToolArguments(call.function.arguments),
+        })),
+        usage: data.usage
+          ? {
+              promptTokens: data.usage.prompt_tokens,
+              completionTokens: data.usage.completion_tokens,
+              totalTokens: data.usage.total_tokens,
+            }
+          : undefined,
+      };
+    },
+
+    addToolResults(results) {
+      if (lastMessage) {
+        messages.push(lastMessage);
+      }
+      for (const result of results) {
+        messages.push({
+          role: "tool",
+          tool_call_id: result.id,
+          content: result.content,
+        });
+      }
+    },
+
+    transcript() {
+      return JSON.stringify(messages);
+    },
+  };
+}
+
+interface AnthropicContentBlock {
+  type: string;
+  text?: string;
+  id?: string;
+  name?: string;
+  input?: Record<string, unknown>;
+}
+
+interface AnthropicToolResponse {
+  content?: AnthropicContentBlock[];
+  usage?: AnthropicResponse["usage"];
+}
+
+function createAnthropicConversation(
+  options: LLMRequestOptions,
+  tools: ToolDefinition[],
+): ToolConversation {
+  const { config } = options;
+  const messages: Array<Record<string, unknown>> = [
+    { role: "user", content: options.userPrompt },
+  ];
+  let lastContent: AnthropicContentBlock[] = [];
+
+  return {
+    async send(allowTools) {
+      const url = `${config.baseUrl}/v1/messages`;
+      const body = {
+        model: config.model,
+        max_tokens: config.maxTokens,
+        system: options.systemPrompt,
+        messages,
+        tools: tools.map((tool) => ({
+          name: tool.name,
+          description: tool.description,
+          input_schema: tool.parameters,
+        })),
+        tool_choice: { type: allowTools ? "auto" : "none" },
+      };
+
+      const headers: Record<string, string> = {
+        "Content-Type": "application/json",
+        "anthropic-version": "2023-06-01",
+      };
+      if (config.apiKey) {
+        headers["x-api-key"] = config.apiKey;
+      }
+
+      logger.debug(`Sending tool request to Anthropic at ${url}`);
+
+      const response = await fetch(url, {
+        method: "POST",
+        headers,
+        body: JSON.stringify(body),
+        signal: requestSignal(config.timeout, options.signal),
+      });
+
+      if (!response.ok) {
+        const errorText = await response.text().catch(() => "Unknown error");
+        throw new LLMError(
+          `Anthropic API error: ${response.status} ${response.statusText}`,
+          response.status,
+          errorText,
+        );
+      }
+
+      const data = (await response.json()) as AnthropicToolResponse;
+      lastContent = data.content ?? [];
+
+      return {
+        content: lastContent
+          .filter((block) => block.type === "text")
+          .map((block) => block.text)
+          .join(""),
+        toolCalls: lastContent
+          .filter((block) => block.type === "tool_use")
+          .map((block) => ({
+            id: block.id ?? "",
+            name: block.name ?? "",
+            input: block.input ?? {},
+          })),
+        usage: data.usage
+          ? {
+              promptTokens: data.usage.input_tokens,
+              completionTokens: data.usage.output_tokens,
+              totalTokens: data.usage.input_tokens + data.usage.output_tokens,
+            }
+          : undefined,
+      };
+    },
+
+    addToolResults(results) {
+      messages.push({ role: "assistant", content: lastContent });
+      messages.push({
+        role: "user",
+        content: results.map((result) => ({
+          type: "tool_result",
+          tool_use_id: result.id,
+          content: result.content,
+        })),
+      });
+    },
+
+    transcript() {
+      return `${options.systemPrompt}\n\n${JSON.stringify(messages)}`;
+    },
+  };
+}
+
+function parseToolArguments(args: string): Record<string, unknown> {
+  try {
+    const parsed = JSON.parse(args);
+    return typeof parsed === "object" && parsed !== null ? parsed : {};
+  } catch {
+    return {};
+  }
+}
+
+const TOOL_CONVERSATION_MAP: Partial<
+  Record<
+    LLMProvider,
+    (options: LLMRequestOptions, tools: ToolDefinition[]) => ToolConversation
+  >
+> = {
+  openai: createOpenAIConversation,
+  "openai-compatible": createOpenAIConversation,
+  anthropic: createAnthropicConversation,
+};
+
+/**
+ * Check whether a provider supports tool calling.
+ */
+export function supportsToolCalling(provider: LLMProvider): boolean {
+  return provider in TOOL_CONVERSATION_MAP;
+}
+
 // ============================================================================
 // Main Request Handler
 // ============================================================================
@@ -354,16 +658,192 @@ export async function sendLLMRequest(
   const queue = getGlobalRequestQueue();
   const requestId = options.requestId || `req-${Date.now()}`;
 
+  const requestFn = PROVIDER_REQUEST_MAP[options.config.provider];
+  if (!requestFn) {
+    throw new Error(`Unknown LLM provider: ${options.config.provider}`);
+  }
+
+  return queue.enqueue(
+    requestId,
+    (signal) => {
+      const request = { ...options, signal };
+      return executeAuditedLLMRequest(
+        request,
+        `${options.systemPrompt}\n\n${options.userPrompt}`,
+        options.contextFiles ?? [],
+        () => requestFn(request),
+        { content: "[]" },
+      );
+    },
+    options.signal,
+  );
+}
+
+/**
+ * Send a request that lets the model call tools before answering.
+ * Tool calls are executed locally; the loop ends when the model answers
+ * without tool calls or the call budget is spent. Falls back to a plain
+ * request for providers without tool calling.
+ */
+export async function sendLLMRequestWithTools(
+  options: LLMRequestOptions,
+  toolOptions: ToolLoopOptions,
+): Promise<LLMResponse> {
+  const createConversation = TOOL_CONVERSATION_MAP[options.config.provider];
+  if (!createConversation) {
+    logger.debug(
+      `Tool calling not supported by ${options.config.provider}, sending plain request`,
+    );
+    return sendLLMRequest(options);
+  }
+
+  const queue = getGlobalRequestQueue();
+  const requestId = options.requestId || `req-${Date.now()}`;
+
   return queue.enqueue(
     requestId,
-    () => executeLLMRequest(options),
+    (signal) => {
+      const request = { ...options, signal };
+      return runToolLoop(
+        request,
+        toolOptions,
+        createConversation(request, toolOptions.tools),
+      );
+    },
     options.signal,
   );
 }
 
-async function executeLLMRequest(
+async function runToolLoop(
   options: LLMRequestOptions,
+  toolOptions: ToolLoopOptions,
+  conversation: ToolConversation,
 ): Promise<LLMResponse> {
+  const usage = { promptTokens: 0, completionTokens: 0, totalTokens: 0 };
+  let calls = 0;
+  let contextFiles: string[] = options.contextFiles ?? [];
+
+  while (true) {
+    const allowTools = calls < toolOptions.maxCalls;
+    const turn = await executeAuditedLLMRequest(
+      options,
+      conversation.transcript(),
+      contextFiles,
+      () => conversation.send(allowTools),
+      { content: "[]", toolCalls: [] },
+    );
+
+    if (turn.usage) {
+      usage.promptTokens += turn.usage.promptTokens;
+      usage.completionTokens += turn.usage.completionTokens;
+      usage.totalTokens += turn.usage.totalTokens;
+    }
+
+    if (turn.toolCalls.length === 0 || !allowTools) {
+      logger.debug(`Tool loop finished after ${calls} tool call(s)`);
+      return { content: turn.content, usage };
+    }
+
+    const results: Array<{ id: string; content: string }> = [];
+    contextFiles = [...(options.contextFiles ?? [])];
+
+    for (const call of turn.toolCalls) {
+      if (calls >= toolOptions.maxCalls) {
+        results.push({
+          id: call.id,
+          content:
+            "Tool call budget exhausted. Answer with the information you have.",
+        });
+        continue;
+      }
+
+      calls++;
+      logger.debug(`Tool call ${calls}: ${call.name}`, call.input);
+
+      try {
+        const result = await toolOptions.execute(call);
+        results.push({ id: call.id, content: result.content });
+        contextFiles.push(...(result.files ?? []));
+      } catch (error) {
+        results.push({
+          id: call.id,
+          content: `Error: ${error instanceof Error ? error.message : "tool failed"}`,
+        });
+      }
+    }
+
+    conversation.addToolResults(results);
+  }
+}
+
+async function executeAuditedLLMRequest<T extends LLMResponse>(
+  options: LLMRequestOptions,
+  content: string,
+  contextFiles: string[],
+  send: () => Promise<T>,
+  fallback: T,
+): Promise<T> {
+  const { auditLogPath } = options;
+  if (!auditLogPath) {
+    return executeLLMRequest(options, send, fallback);
+  }
+
+  // Record the request when it is first sent, not when the budget or a
+  // cancellation stops it before it goes out
+  let finish: ((outcome: AuditOutcome) => void) | undefined;
+  const auditedSend = () => {
+    finish ??= beginAuditEntry(auditLogPath, {
+      config: options.config,
+      content,
+      filePath: options.filePath,
+      contextFiles: contextFiles.length > 0 ? contextFiles : undefined,
+    });
+    return send();
+  };
+
+  try {
+    const response = await executeLLMRequest(options, auditedSend, fallback);
+    finish?.({ usage: response.usage, status: "ok" });
+    return response;
+  } catch (error) {
+    finish?.({ status: "error" });
+    throw error;
+  }
+}
+
+// Requests sent to providers by this process, retries and tool turns
+// included, for request budgets
+let requestCount = 0;
+let requestBudget: { limit: number; endsAt: number } | undefined;
+let requestBudgetExceeded = false;
+
+export function getLLMRequestCount(): number {
+  return requestCount;
+}
+
+/**
+ * Refuse requests with RequestBudgetError once limit more have been sent,
+ * wherever they come from: analysis, retries, tool turns or verification.
+ * undefined lifts the budget.
+ */
+export function setLLMRequestBudget(limit: number | undefined): void {
+  requestBudget =
+    limit === undefined ? undefined : { limit, endsAt: requestCount + limit };
+  requestBudgetExceeded = false;
+}
+
+/**
+ * Whether a request was refused since the budget was set.
+ */
+export function isLLMRequestBudgetExceeded(): boolean {
+  return requestBudgetExceeded;
+}
+
+async function executeLLMRequest<T extends LLMResponse>(
+  options: LLMRequestOptions,
+  send: () => Promise<T>,
+  fallback: T,
+): Promise<T> {
   // Only apply rate limiting if enabled (default: true)
   if (options.rateLimitEnabled !== false) {
     const rateLimiter = getGlobalRateLimiter(options.rateLimitPerMinute ?? 10);
@@ -374,20 +854,26 @@ async function executeLLMRequest(
   const maxRateLimitRetries = 5; // Max retries for rate limiting before giving up
   let rateLimitRetries = 0;
 
-  const requestFn = PROVIDER_REQUEST_MAP[options.config.provider];
-  if (!requestFn) {
-    throw new Error(`Unknown LLM provider: ${options.config.provider}`);
-  }
-
   let attempt = 0;
   while (true) {
+    // Don't retry a cancelled request
+    if (options.signal?.aborted) {
+      throw new Error("Request cancelled");
+    }
+
+    if (requestBudget && requestCount >= requestBudget.endsAt) {
+      requestBudgetExceeded = true;
+      throw new RequestBudgetError(requestBudget.limit);
+    }
+
     attempt++;
+    requestCount++;
     try {
       logger.debug(
         `LLM request attempt ${attempt} to ${options.config.provider}`,
       );
 
-      const response = await requestFn(options);
+      const response = await send();
 
       logger.debug("LLM request successful", response.usage);
       return response;
@@ -407,7 +893,7 @@ async function executeLLMRequest(
             logger.debug(
               `Rate limit retries exhausted, falling back to local analysis`,
             );
-            return { content: "[]" };
+            return fallback;
           }
           // Cap wait time at 30 seconds
           const waitTime = Math.min(
@@ -445,6 +931,29 @@ async function executeLLMRequest(
   }
 }
 
+/**
+ * Signal for a provider request: aborts on timeout or when the request is
+ * cancelled. Keeps the timeout's TimeoutError so timeouts are retried.
+ */
+function requestSignal(timeoutMs: number, signal?: AbortSignal): AbortSignal {
+  const timeout = AbortSignal.timeout(timeoutMs);
+  if (!signal) {
+    return timeout;
+  }
+
+  const controller = new AbortController();
+  for (const source of [timeout, signal]) {
+    if (source.aborted) {
+      controller.abort(source.reason);
+    } else {
+      source.addEventListener("abort", () => controller.abort(source.reason), {
+        once: true,
+      });
+    }
+  }
+  return controller.signal;
+}
+
 function sleep(ms: number): Promise<void> {
   return new Promise((resolve) => setTimeout(resolve, ms));
 }
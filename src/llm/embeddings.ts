@@ -0,0 +1,119 @@
+import type {
+  AilintConfig,
+  ResolvedLLMConfig,
+  RetrievalConfig,
+} from "../types/config.js";
+import { logger } from "../utils/logger.js";
+import { LLMError } from "./client.js";
+import { beginAuditEntry } from "./audit-log.js";
+
+export interface EmbeddingRequestOptions {
+  config: ResolvedLLMConfig;
+  filePath?: string; // Source file the texts come from (for auditing)
+  auditLogPath?: string;
+}
+
+interface OllamaEmbedResponse {
+  embeddings?: number[][];
+  prompt_eval_count?: number;
+}
+
+const MAX_BATCH_SIZE = 32;
+
+/**
+ * Endpoint config for the embedding model, treated as an Ollama provider so
+ * egress policy and audit logging apply to it like to the analysis model.
+ */
+export function resolveEmbeddingConfig(
+  retrieval: RetrievalConfig,
+  config: Pick<AilintConfig, "llm">,
+): ResolvedLLMConfig {
+  return {
+    provider: "ollama",
+    baseUrl: retrieval.baseUrl,
+    model: retrieval.model,
+    timeout: config.llm.timeout,
+    maxTokens: 0,
+  };
+}
+
+/**
+ * Compute embeddings with the Ollama embed endpoint.
+ * Returns one vector per input text, in order.
+ */
+export async function requestEmbeddings(
+  texts: string[],
+  options: EmbeddingRequestOptions,
+): Promise<number[][]> {
+  const vectors: number[][] = [];
+
+  for (let i = 0; i < texts.length; i += MAX_BATCH_SIZE) {
+    const batch = texts.slice(i, i + MAX_BATCH_SIZE);
+    vectors.push(...(await requestEmbeddingBatch(batch, options)));
+  }
+
+  return vectors;
+}
+
+async function requestEmbeddingBatch(
+  texts: string[],
+  options: EmbeddingRequestOptions,
+): Promise<number[][]> {
+  const { config, filePath, auditLogPath } = options;
+  const url = `${config.baseUrl}/api/embed`;
+
+  logger.debug(`Requesting ${texts.length} embedding(s) from ${url}`);
+
+  const finish = auditLogPath
+    ? beginAuditEntry(auditLogPath, {
+        config,
+        content: texts.join("\n"),
+        filePath,
+      })
+    : undefined;
+  const audit = (status: "ok" | "error", promptTokens?: number) => {
+    finish?.({
+      usage:
+        promptTokens !== undefined
+          ? { promptTokens, completionTokens: 0 }
+          : undefined,
+      status,
+    });
+  };
+
+  let data: OllamaEmbedResponse;
+  try {
+    const response = await fetch(url, {
+      method: "POST",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify({ model: config.model, input: texts }),
+      signal: AbortSignal.timeout(config.timeout),
+    });
+
+    if (!response.ok) {
+      const errorText = await response.text().catch(() => "Unknown error");
+      throw new LLMError(
+        `Ollama embed error: ${response.status} ${response.statusText}`,
+        response.status,
+        errorText,
+      );
+    }
+
+    data = (await response.json()) as OllamaEmbedResponse;
+  } catch (error) {
+    audit("error");
+    throw error;
+  }
+
+  audit("ok", data.prompt_eval_count);
+
+  const embeddings = data.embeddings ?? [];
+  if (embeddings.length !== texts.length) {
+    throw new LLMError(
+      `Ollama embed returned ${embeddings.length} vector(s) for ${texts.length} input(s)`,
+    );
+  }
+  return embeddings;
+}
@@ -3,3 +3,5 @@ export * from "./prompt-builder.js";
 export * from "./response-parser.js";
 export * from "./rate-limiter.js";
 export * from "./request-queue.js";
+export * from "./audit-log.js";
+export * from "./embeddings.js";
@@ -1,5 +1,36 @@
 import type { RulesConfig } from "../types/config.js";
-import { getLanguageForExtension } from "../core/languages.js";
+import { FindingCategorySchema, type Finding } from "../types/finding.js";
+import type { FrameworkConfig } from "../core/frameworks.js";
+import type { RelatedSnippet } from "../core/embedding-index.js";
+import type { ExternalDiagnostic } from "../core/external-results.js";
+import type { TriagePromptGroup } from "../core/triage.js";
+import type { CoverageSummary } from "../core/coverage.js";
+import {
+  getLanguageById,
+  getLanguageForExtension,
+} from "../core/languages.js";
+import { splitLines } from "../core/positions.js";
+
+// Listed from the schema, so new categories reach the prompts
+const CATEGORIES = FindingCategorySchema.options.join(", ");
+
+/**
+ * Extra context that shapes the system prompt beyond rules and language.
+ */
+export interface PromptContext {
+  toolsEnabled?: boolean;
+  projectFacts?: string[]; // Toolchain versions and settings of the project
+  frameworks?: FrameworkConfig[]; // Frameworks the file uses
+}
+
+/**
+ * Extra material included in the user prompt next to the analyzed file.
+ */
+export interface UserPromptContext {
+  relatedCode?: RelatedSnippet[]; // Similar code retrieved from the index
+  knownIssues?: ExternalDiagnostic[]; // Already reported by other linters
+  coverage?: CoverageSummary; // Test coverage of the file
+}
 
 /**
  * Build the system prompt for code analysis.
@@ -7,6 +38,7 @@ import { getLanguageForExtension } from "../core/languages.js";
 export function buildSystemPrompt(
   rules: RulesConfig,
   languageId?: string,
+  context: PromptContext = {},
 ): string {
   const lang = languageId
     ? getLanguageForExtension(`.${languageId}`) || getLanguageById(languageId)
@@ -46,19 +78,43 @@ export function buildSystemPrompt(
       "- TYPE SAFETY: Explicit 'any' type, unsafe type assertions, missing null checks",
     );
   }
+  if (rules.documentation) {
+    enabledRules.push(
+      '- DOCUMENTATION (category "documentation"): Missing doc comments on exported Go identifiers and public TypeScript/JavaScript APIs, comments that contradict what the code does, TODO/FIXME comments with no owner or issue reference, parameter and return docs that do not match the signature',
+    );
+  }
 
   const langInstructions = lang?.promptInstructions || "";
 
+  const projectFacts = context.projectFacts?.length
+    ? `## Project settings:
+${context.projectFacts.map((fact) => `- ${fact}`).join("\n")}
+Only suggest fixes that work with these versions and settings.
+`
+    : "";
+
+  const frameworks = rules.frameworks ? (context.frameworks ?? []) : [];
+  const frameworkInstructions = frameworks
+    .map((f) => `## ${f.name} guidance:\n${f.promptInstructions}\n`)
+    .join("\n");
+
+  const toolInstructions = context.toolsEnabled
+    ? `## Tools:
+You can call read_file, find_definition and grep to look at other code in the repository. Use them to verify a suspicion before reporting it (e.g. whether an error is handled by the caller, or what a called function returns) instead of guessing. Keep tool calls few and targeted. When you are done, answer with the findings JSON only.
+`
+    : "";
+
   return `You are a ${langName} code quality analyzer. Identify genuine code issues and return structured findings.
 
 ## Categories to check:
 ${enabledRules.join("\n")}
 
-${langInstructions ? `## Language-specific guidance:\n${langInstructions}\n` : ""}
+${langInstructions ? `## Language-specific guidance:\n${langInstructions}\n` : ""}${frameworkInstructions}${projectFacts}${toolInstructions}
 ## Rules:
 - Be precise and actionable - every finding must have a clear fix
 - Only report real issues, not style preferences
 - Include specific line numbers (1-indexed) when possible
+- Count columns (0-indexed) in characters; a tab is one character
 - Set confidence 0.0-1.0 based on certainty
 - Prioritize issues that could cause bugs or maintenance problems
 
@@ -79,12 +135,16 @@ export function buildUserPrompt(
   filePath: string,
   content: string,
   languageId?: string,
+  context: UserPromptContext = {},
 ): string {
   const lang = languageId
     ? getLanguageForExtension(`.${languageId}`) || getLanguageById(languageId)
     : null;
   const langName = lang?.id || "code";
-  const lineCount = content.split("\n").length;
+  const lineCount = splitLines(content).length;
+  const relatedCode = formatRelatedCode(context.relatedCode ?? [], langName);
+  const knownIssues = formatKnownIssues(context.knownIssues ?? []);
+  const coverage = formatCoverage(context.coverage);
 
   return `Analyze this ${lang?.name || "code"} file for quality issues.
 
@@ -94,7 +154,7 @@ Lines: ${lineCount}
 \`\`\`${langName}
 ${content}
 \`\`\`
-
+${relatedCode}${knownIssues}${coverage}
 Return findings as a JSON array:
 [
   {
@@ -114,23 +174,256 @@ Return findings as a JSON array:
   }
 ]
 
-Categories: smell, practice, spaghetti, naming, safety
+Categories: ${CATEGORIES}
 Severities: error, warning, info, hint
 
 Respond with ONLY the JSON array, no other text.`;
 }
 
 /**
- * Helper to get language by ID (for when we pass "go" instead of ".go")
+ * Build user prompt for several files of one package or module.
+ * Findings must name the file they belong to.
+ */
+export function buildUnitUserPrompt(
+  files: Array<{
+    filePath: string;
+    content: string;
+    knownIssues?: ExternalDiagnostic[];
+    coverage?: CoverageSummary;
+  }>,
+  languageId?: string,
+): string {
+  const lang = languageId
+    ? getLanguageForExtension(`.${languageId}`) || getLanguageById(languageId)
+    : null;
+  const langName = lang?.id || "code";
+
+  const sections = files.map((f) => {
+    const extra =
+      formatKnownIssues(f.knownIssues ?? []) + formatCoverage(f.coverage);
+    return `File: ${f.filePath}\nLines: ${splitLines(f.content).length}\n\n\`\`\`${langName}\n${f.content}\n\`\`\`${extra && `\n${extra}`}`;
+  });
+
+  return `Analyze these ${files.length} ${lang?.name || "code"} files together. They belong to the same package or module, so functions, methods, types and state defined in one file may be used in another. Do not report something as missing or undefined if it is defined in a sibling file.
+
+${sections.join("\n\n")}
+
+Return findings as a JSON array. Every finding MUST include "file" with the path exactly as given above, and line numbers relative to that file:
+[
+  {
+    "id": "AI001",
+    "file": "${files[0]?.filePath ?? "path/to/file"}",
+    "title": "Short descriptive title",
+    "severity": "warning",
+    "message": "Detailed explanation of the issue",
+    "suggestion": "Specific recommendation to fix it",
+    "category": "smell",
+    "confidence": 0.85,
+    "range": {
+      "startLine": 10,
+      "startCharacter": 0,
+      "endLine": 15,
+      "endCharacter": 1
+    }
+  }
+]
+
+Categories: ${CATEGORIES}
+Severities: error, warning, info, hint
+
+Respond with ONLY the JSON array, no other text.`;
+}
+
+/**
+ * Build the system prompt for the verifier pass, which reviews one finding
+ * at a time and judges whether it is a real issue.
+ */
+export function buildVerifierSystemPrompt(languageId?: string): string {
+  const lang = languageId
+    ? getLanguageForExtension(`.${languageId}`) || getLanguageById(languageId)
+    : null;
+  const langName = lang?.name || "code";
+
+  return `You are a senior ${langName} reviewer double-checking findings reported by an automated code analyzer. The analyzer is often confidently wrong: it misreads control flow, misses handling done nearby, or flags idiomatic code.
+
+For the finding you are given, read the code excerpt and decide whether the finding describes a real issue in that code.
+
+## Rules:
+- Answer "valid": false if the claim is factually wrong about the code, the issue is already handled, or it is only a style preference
+- Answer "valid": true only if you can point to the code that causes the issue
+- If the issue is real but overstated, keep it valid and lower the severity
+- Set confidence 0.0-1.0 for your own verdict
+
+## Output format:
+Return ONLY a JSON object, no markdown:
+{"valid": true, "severity": "warning", "confidence": 0.8, "reason": "One sentence explaining why"}`;
+}
+
+/**
+ * Build the user prompt for verifying a single finding.
  */
-function getLanguageById(id: string) {
-  const extMap: Record<string, string> = {
-    typescript: ".ts",
-    go: ".go",
-    python: ".py",
-    rust: ".rs",
-    java: ".java",
-  };
-  const ext = extMap[id];
-  return ext ? getLanguageForExtension(ext) : undefined;
+export function buildVerifierUserPrompt(
+  filePath: string,
+  excerpt: string,
+  finding: Finding,
+  languageId?: string,
+): string {
+  const lang = languageId
+    ? getLanguageForExtension(`.${languageId}`) || getLanguageById(languageId)
+    : null;
+  const langName = lang?.id || "code";
+  const location = finding.range
+    ? `lines ${finding.range.startLine + 1}-${finding.range.endLine + 1}`
+    : "no specific lines";
+
+  return `File: ${filePath}
+
+Reported finding (${location}):
+- Title: ${finding.title}
+- Severity: ${finding.severity}
+- Category: ${finding.category}
+- Message: ${finding.message}
+- Suggestion: ${finding.suggestion}
+
+Code excerpt (line numbers on the left):
+\`\`\`${langName}
+${excerpt}
+\`\`\`
+
+Is this a real issue? Respond with ONLY the JSON object.`;
+}
+
+/**
+ * Build the system prompt for triaging diagnostics reported by other linters.
+ */
+export function buildTriageSystemPrompt(): string {
+  return `You are a senior engineer triaging a large backlog of linter warnings that nobody has had time to read. Warnings are grouped by tool and rule; for each group you see how often it occurs and a few samples with the surrounding code.
+
+For each group decide how much attention it deserves:
+- "high": likely real bugs, security or data-loss problems; fix soon
+- "medium": real issues with limited impact, or worth a cleanup pass
+- "low": style, noise, or rules that do not fit this codebase
+
+## Rules:
+- Judge the samples' code, not just the rule name
+- Explain in one or two sentences what the problem is and why it matters (or does not) here; for high priority groups say how to fix it
+- List sample IDs that are false positives (the warning is wrong about that code)
+- Set "likelyFalsePositive": true if the rule is mostly wrong or irrelevant in this codebase
+
+## Output format:
+Return ONLY a JSON array with one object per group, no markdown:
+[{"group": "G1", "priority": "high", "explanation": "...", "falsePositives": ["G1.2"], "likelyFalsePositive": false}]`;
+}
+
+/**
+ * Build the user prompt with a batch of grouped diagnostics.
+ */
+export function buildTriageUserPrompt(groups: TriagePromptGroup[]): string {
+  const sections = groups.map((group) => {
+    const samples = group.samples.map((sample) => {
+      const code = sample.excerpt
+        ? `\n\`\`\`${sample.languageId ?? ""}\n${sample.excerpt}\n\`\`\``
+        : "\n(code not available)";
+      return `Sample ${sample.id} at ${sample.location}: ${sample.message}${code}`;
+    });
+
+    return `### Group ${group.id}: ${group.source}${group.rule ? ` ${group.rule}` : ""}
+${group.count} occurrence(s) in ${group.fileCount} file(s)
+
+${samples.join("\n\n")}`;
+  });
+
+  return `Triage these ${groups.length} group(s) of linter warnings.
+
+${sections.join("\n\n")}
+
+Respond with ONLY the JSON array, one object per group.`;
+}
+
+/**
+ * Format retrieved snippets as reference material for consistency checks.
+ */
+function formatRelatedCode(
+  snippets: RelatedSnippet[],
+  langName: string,
+): string {
+  if (snippets.length === 0) {
+    return "";
+  }
+
+  const sections = snippets.map(
+    (s) =>
+      `${s.file} (lines ${s.startLine}-${s.endLine}):\n\`\`\`${langName}\n${s.text}\n\`\`\``,
+  );
+
+  return `
+## Related code from this repository (for reference only):
+These snippets show how similar things are done elsewhere in the codebase. Use them to judge whether the file above follows the project's conventions. Do NOT report issues in the snippets themselves; line numbers in findings refer to the file above.
+
+${sections.join("\n\n")}
+`;
+}
+
+const MAX_UNTESTED_FUNCTIONS = 30;
+
+/**
+ * Format coverage facts and ask for findings on complex untested code.
+ */
+function formatCoverage(coverage: CoverageSummary | undefined): string {
+  if (!coverage || coverage.instrumentedLines === 0) {
+    return "";
+  }
+
+  const percent = Math.round(coverage.ratio * 100);
+  const lines = [
+    `${percent}% of ${coverage.instrumentedLines} instrumented lines are covered by tests.`,
+  ];
+
+  if (coverage.untested.length > 0) {
+    lines.push("Functions with no test coverage:");
+    for (const f of coverage.untested.slice(0, MAX_UNTESTED_FUNCTIONS)) {
+      lines.push(`- Lines ${f.startLine}-${f.endLine}: ${f.name}`);
+    }
+    if (coverage.untested.length > MAX_UNTESTED_FUNCTIONS) {
+      lines.push(
+        `- ... and ${coverage.untested.length - MAX_UNTESTED_FUNCTIONS} more`,
+      );
+    }
+    lines.push(
+      'Report untested code that is complex (many branches, error handling, state changes or calculations a regression could hide in) as a finding with category "untested" on the function\'s lines. Do not report simple getters, wiring or trivial code.',
+    );
+  }
+
+  return `
+## Test coverage:
+${lines.join("\n")}
+`;
+}
+
+const MAX_KNOWN_ISSUES = 50;
+
+/**
+ * Format diagnostics from other linters so the model does not repeat them.
+ */
+function formatKnownIssues(diagnostics: ExternalDiagnostic[]): string {
+  if (diagnostics.length === 0) {
+    return "";
+  }
+
+  const lines = diagnostics
+    .slice(0, MAX_KNOWN_ISSUES)
+    .map(
+      (d) =>
+        `- Line ${d.line}: [${d.source}${d.rule ? ` ${d.rule}` : ""}] ${d.message}`,
+    );
+  if (diagnostics.length > MAX_KNOWN_ISSUES) {
+    lines.push(`- ... and ${diagnostics.length - MAX_KNOWN_ISSUES} more`);
+  }
+
+  return `
+## Already reported by other tools:
+These issues are already reported by the project's linters. Do NOT report them again; focus on problems these tools cannot find.
+
+${lines.join("\n")}
+`;
 }
@@ -2,7 +2,7 @@ import { logger } from "../utils/logger.js";
 
 interface QueuedRequest<T> {
   id: string;
-  execute: () => Promise<T>;
+  execute: (signal: AbortSignal) => Promise<T>;
   resolve: (value: T) => void;
   reject: (error: Error) => void;
   abortController: AbortController;
@@ -17,10 +17,12 @@ interface QueuedRequest<T> {
  * - Only one request processed at a time
  * - Newer requests for same file cancel older ones
  * - Stale requests are automatically cancelled
+ * - cancelAll() also aborts the request in flight
  */
 export class RequestQueue {
   private queue: QueuedRequest<unknown>[] = [];
   private processing = false;
+  private current: QueuedRequest<unknown> | null = null;
   private maxQueueAge = 30000; // 30 seconds max wait time
 
   /**
@@ -28,12 +30,13 @@ export class RequestQueue {
    * Returns a promise that resolves when the request completes.
    *
    * @param id - Unique ID for the request (e.g., file path)
-   * @param execute - Function that executes the request
+   * @param execute - Function that executes the request; it should pass the
+   *   signal it gets on to fetch so cancellation stops the request
    * @param signal - Optional AbortSignal to cancel the request
    */
   async enqueue<T>(
     id: string,
-    execute: () => Promise<T>,
+    execute: (signal: AbortSignal) => Promise<T>,
     signal?: AbortSignal,
   ): Promise<T> {
     // Cancel any existing requests for the same ID
@@ -88,10 +91,11 @@ export class RequestQueue {
   }
 
   /**
-   * Cancel all pending requests.
+   * Cancel all pending requests and abort the one in flight.
    */
   cancelAll(): void {
-    for (const request of this.queue) {
+    const requests = this.current ? [this.current, ...this.queue] : this.queue;
+    for (const request of requests) {
       request.abortController.abort();
       request.reject(new Error("All requests cancelled"));
     }
@@ -138,6 +142,7 @@ export class RequestQueue {
 
     this.processing = true;
     const request = this.queue.shift()!;
+    this.current = request;
 
     try {
       // Check if request was aborted while waiting
@@ -147,12 +152,13 @@ export class RequestQueue {
       }
 
       logger.debug(`Processing request: ${request.id}`);
-      const result = await request.execute();
+      const result = await request.execute(request.abortController.signal);
       request.resolve(result);
     } catch (error) {
       request.reject(error as Error);
     } finally {
       this.processing = false;
+      this.current = null;
       // Process next request if any
       this.processNext();
     }
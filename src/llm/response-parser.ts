@@ -1,4 +1,9 @@
-import { FindingsArraySchema, type Finding } from "../types/finding.js";
+import {
+  FindingCategorySchema,
+  FindingsArraySchema,
+  type Finding,
+  type FindingCategory,
+} from "../types/finding.js";
 import { extractJSON } from "../utils/json-extract.js";
 import { logger } from "../utils/logger.js";
 
@@ -61,7 +66,7 @@ export function parseResponse(response: string): ParseResult {
     }
 
     if (salvaged.length > 0) {
-      result.findings = salvaged;
+      result.findings = salvaged.map(toZeroBasedLines);
       result.parseError = `Partial parse: ${salvaged.length}/${dataArray.length} findings valid`;
     } else {
       result.parseError = "No valid findings in LLM response";
@@ -70,10 +75,29 @@ export function parseResponse(response: string): ParseResult {
     return result;
   }
 
-  result.findings = validated.data;
+  result.findings = validated.data.map(toZeroBasedLines);
   return result;
 }
 
+/**
+ * The prompt asks for lines counted from 1; findings count them from 0, as
+ * editors and the rest of the pipeline do.
+ */
+function toZeroBasedLines(finding: Finding): Finding {
+  if (!finding.range) {
+    return finding;
+  }
+  const { startLine, endLine } = finding.range;
+  return {
+    ...finding,
+    range: {
+      ...finding.range,
+      startLine: Math.max(0, startLine - 1),
+      endLine: Math.max(0, endLine - 1),
+    },
+  };
+}
+
 /**
  * Attempt to fix/normalize a single finding object.
  */
@@ -104,12 +128,13 @@ function sanitizeFinding(raw: unknown): Finding | null {
   }
 
   // Category with fallback
-  let category: "smell" | "practice" | "spaghetti" | "naming" | "safety" =
-    "practice";
+  let category: FindingCategory = "practice";
   if (typeof obj["category"] === "string") {
-    const cat = obj["category"].toLowerCase();
-    if (["smell", "practice", "spaghetti", "naming", "safety"].includes(cat)) {
-      category = cat as typeof category;
+    const parsed = FindingCategorySchema.safeParse(
+      obj["category"].toLowerCase(),
+    );
+    if (parsed.success) {
+      category = parsed.data;
     }
   }
 
@@ -138,6 +163,9 @@ function sanitizeFinding(raw: unknown): Finding | null {
     }
   }
 
+  // Source file (multi-file analysis only)
+  const file = typeof obj["file"] === "string" ? obj["file"] : undefined;
+
   // Skip findings without meaningful content
   if (!message && !title) {
     return null;
@@ -152,6 +180,7 @@ function sanitizeFinding(raw: unknown): Finding | null {
     category,
     confidence,
     range,
+    ...(file && { file }),
   };
 }
 
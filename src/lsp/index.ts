@@ -1 +1,2 @@
 export * from "./server.js";
+export * from "./uri.js";
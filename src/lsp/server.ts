@@ -14,9 +14,17 @@ import { analyze } from "../core/analyzer.js";
 import {
   findingsToDiagnostics,
   createErrorDiagnostic,
+  type DocumentPositions,
 } from "../core/diagnostics-mapper.js";
 import { debounce } from "../core/debounce.js";
+import {
+  negotiatePositionEncoding,
+  splitLines,
+  type PositionEncoding,
+} from "../core/positions.js";
+import { detectSkipReason } from "../core/skip-detect.js";
 import { logger } from "../utils/logger.js";
+import { documentLocation, uriToPath } from "./uri.js";
 import type { AilintConfig } from "../types/config.js";
 
 export function startLSPServer(): void {
@@ -33,6 +41,8 @@ export function startLSPServer(): void {
   // State
   let config: AilintConfig;
   let rootUri: string | null = null;
+  let rootPath: string = process.cwd();
+  let positionEncoding: PositionEncoding = "utf-16";
   const documentStore = getGlobalDocumentStore();
 
   // Debounced analysis function per document
@@ -47,7 +57,7 @@ export function startLSPServer(): void {
       rootUri = params.rootUri || params.workspaceFolders?.[0]?.uri || null;
 
       // Load config
-      const rootPath = rootUri ? new URL(rootUri).pathname : process.cwd();
+      rootPath = (rootUri && uriToPath(rootUri)) || process.cwd();
       config = loadConfig(rootPath);
 
       if (config.debug) {
@@ -62,10 +72,15 @@ export function startLSPServer(): void {
         logger.warn(apiKeyValidation.message);
       }
 
+      positionEncoding = negotiatePositionEncoding(
+        params.capabilities.general?.positionEncodings,
+      );
+
       logger.info("lintai LSP server initialized");
 
       return {
         capabilities: {
+          positionEncoding,
           textDocumentSync: {
             openClose: true,
             change: TextDocumentSyncKind.Full,
@@ -95,13 +110,14 @@ export function startLSPServer(): void {
         cached,
         config.severity,
         event.document.lineCount,
+        documentPositions(event.document.getText()),
       );
       connection.sendDiagnostics({ uri, diagnostics });
       return;
     }
 
     // Trigger analysis
-    triggerAnalysis(uri, event.document.getText(), event.document.lineCount);
+    triggerAnalysis(event.document);
   });
 
   // Document changed
@@ -147,6 +163,10 @@ export function startLSPServer(): void {
     documentStore.delete(uri);
   });
 
+  function documentPositions(content: string): DocumentPositions {
+    return { lines: splitLines(content), encoding: positionEncoding };
+  }
+
   function getOrCreateDebouncedAnalysis(uri: string): DebouncedFn {
     let debouncedFn = analysisQueue.get(uri);
 
@@ -154,7 +174,7 @@ export function startLSPServer(): void {
       debouncedFn = debounce((docUri: string) => {
         const doc = documents.get(docUri);
         if (doc) {
-          triggerAnalysis(docUri, doc.getText(), doc.lineCount);
+          triggerAnalysis(doc);
         }
       }, config.performance.debounceMs);
 
@@ -164,11 +184,9 @@ export function startLSPServer(): void {
     return debouncedFn;
   }
 
-  async function triggerAnalysis(
-    uri: string,
-    content: string,
-    lineCount: number,
-  ): Promise<void> {
+  async function triggerAnalysis(document: TextDocument): Promise<void> {
+    const { uri, languageId, lineCount } = document;
+    const content = document.getText();
     const entry = documentStore.get(uri);
     if (!entry) return;
 
@@ -192,14 +210,27 @@ export function startLSPServer(): void {
         return;
       }
 
-      // Get file path from URI
-      const filePath = new URL(uri).pathname;
+      // Unsaved and virtual documents are analyzed from the buffer too
+      const { filePath, localOnly } = documentLocation(uri, rootPath);
+
+      const skipReason = config.analysis.skipGenerated
+        ? detectSkipReason(filePath, content, rootPath)
+        : null;
+      if (skipReason) {
+        logger.debug(`Skipping ${uri}: ${skipReason}`);
+        documentStore.setFindings(uri, []);
+        connection.sendDiagnostics({ uri, diagnostics: [] });
+        return;
+      }
 
       // Run analysis
       const result = await analyze({
         filePath,
         content,
         config,
+        languageId,
+        rootDir: rootPath,
+        localOnly,
       });
 
       // Store findings
@@ -210,6 +241,7 @@ export function startLSPServer(): void {
         result.findings,
         config.severity,
         lineCount,
+        documentPositions(content),
       );
 
       // Add error diagnostic if analysis had issues
@@ -0,0 +1,58 @@
+import { basename, isAbsolute, join, relative } from "node:path";
+import { fileURLToPath } from "node:url";
+
+export interface DocumentLocation {
+  // Path on disk, or a name under the workspace root for other schemes
+  filePath: string;
+  onDisk: boolean;
+  // Not known to be a workspace path, so path policies cannot be applied
+  localOnly: boolean;
+}
+
+/**
+ * Path of a file: URI, with percent-encoded characters decoded.
+ */
+export function uriToPath(uri: string): string | null {
+  try {
+    return uri.startsWith("file:") ? fileURLToPath(uri) : null;
+  } catch {
+    return null;
+  }
+}
+
+/**
+ * Where a document lives. A virtual document whose path lies under the
+ * workspace root, like git:/repo/src/a.py, keeps that path, so path
+ * policies apply to it as to the file on disk. Others, like untitled:
+ * buffers, get a name under the root for prompts but are local-only,
+ * since no policy can tell whether they may be shared.
+ */
+export function documentLocation(
+  uri: string,
+  rootPath: string,
+): DocumentLocation {
+  const path = uriToPath(uri);
+  if (path) {
+    return { filePath: path, onDisk: true, localOnly: false };
+  }
+
+  let pathname = "";
+  let name = "";
+  try {
+    const url = new URL(uri);
+    pathname = decodeURIComponent(url.pathname);
+    name = basename(pathname) || url.protocol;
+  } catch {
+    name = basename(uri);
+  }
+
+  const rel = isAbsolute(pathname) ? relative(rootPath, pathname) : "";
+  if (rel && !rel.startsWith("..") && !isAbsolute(rel)) {
+    return { filePath: join(rootPath, rel), onDisk: false, localOnly: false };
+  }
+  return {
+    filePath: join(rootPath, name.replace(/:$/, "")),
+    onDisk: false,
+    localOnly: true,
+  };
+}
@@ -66,10 +66,80 @@ export interface ResolvedLLMConfig {
 export const AnalysisModeSchema = z.enum(["full-file", "snippet"]);
 export type AnalysisMode = z.infer<typeof AnalysisModeSchema>;
 
+// What is sent per request: one file, or the files of a package/directory
+export const AnalysisUnitSchema = z.enum(["file", "package"]);
+export type AnalysisUnit = z.infer<typeof AnalysisUnitSchema>;
+
+// SQL dialect of .sql files, for locking and syntax rules
+export const SqlDialectSchema = z.enum([
+  "postgres",
+  "mysql",
+  "sqlite",
+  "sqlserver",
+]);
+export type SqlDialect = z.infer<typeof SqlDialectSchema>;
+
+// Lets the model request more context through local tool calls
+export const ToolsConfigSchema = z.object({
+  enabled: z.boolean().default(false),
+  maxCalls: z.number().int().positive().default(8),
+  maxResultTokens: z.number().positive().default(1500),
+  maxTotalTokens: z.number().positive().default(8000),
+});
+
+export type ToolsConfig = z.infer<typeof ToolsConfigSchema>;
+
+// Retrieval of similar code from the local embedding index (lintai index)
+export const RetrievalConfigSchema = z.object({
+  enabled: z.boolean().default(false),
+  baseUrl: z.string().default("http://localhost:11434"), // Ollama endpoint
+  model: z.string().default("nomic-embed-text"),
+  indexPath: z.string().default(".lintai/index.json"),
+  topK: z.number().int().positive().default(3),
+  minScore: z.number().min(0).max(1).default(0.5),
+  maxTokens: z.number().positive().default(2000),
+});
+
+export type RetrievalConfig = z.infer<typeof RetrievalConfigSchema>;
+
+// Second-opinion pass that confirms, adjusts or drops findings
+export const VerifyConfigSchema = z.object({
+  enabled: z.boolean().default(false),
+  // Only findings at or above this severity are verified
+  minSeverity: z
+    .enum(["error", "warning", "info", "hint"])
+    .default("warning"),
+  // Verifier model; unset fields fall back to the analysis LLM
+  provider: LLMProviderSchema.optional(),
+  baseUrl: z.string().optional(),
+  model: z.string().optional(),
+  apiKey: z.string().optional(),
+});
+
+export type VerifyConfig = z.infer<typeof VerifyConfigSchema>;
+
+// Test coverage reports (Go cover profile, lcov, Cobertura XML)
+export const CoverageConfigSchema = z.object({
+  reports: z.array(z.string()).default([]),
+  // Analyze files with the most untested lines first when maxFiles truncates
+  prioritize: z.boolean().default(false),
+});
+
+export type CoverageConfig = z.infer<typeof CoverageConfigSchema>;
+
 export const AnalysisConfigSchema = z.object({
   mode: AnalysisModeSchema.default("snippet"),
   includeImports: z.boolean().default(false),
   maxFileSize: z.number().positive().default(100000),
+  // Leave out generated, vendored and minified files and lockfiles
+  skipGenerated: z.boolean().default(true),
+  unit: AnalysisUnitSchema.default("file"),
+  maxUnitTokens: z.number().positive().default(16000),
+  tools: ToolsConfigSchema.default({}),
+  retrieval: RetrievalConfigSchema.default({}),
+  verify: VerifyConfigSchema.default({}),
+  coverage: CoverageConfigSchema.default({}),
+  sqlDialect: SqlDialectSchema.optional(),
 });
 
 export type AnalysisConfig = z.infer<typeof AnalysisConfigSchema>;
@@ -81,6 +151,8 @@ export const RulesConfigSchema = z.object({
   naming: z.boolean().default(true),
   errorHandling: z.boolean().default(true),
   anyAbuse: z.boolean().default(true),
+  frameworks: z.boolean().default(true), // Framework-specific guidance
+  documentation: z.boolean().default(false), // Doc comment quality
 });
 
 export type RulesConfig = z.infer<typeof RulesConfigSchema>;
@@ -101,14 +173,55 @@ export const PerformanceConfigSchema = z.object({
 
 export type PerformanceConfig = z.infer<typeof PerformanceConfigSchema>;
 
+// Which files are analyzed first when maxFiles truncates the list
+export const FileOrderSchema = z.enum([
+  "default",
+  "churn",
+  "recent",
+  "size",
+  "random-seeded",
+]);
+export type FileOrder = z.infer<typeof FileOrderSchema>;
+
+export const OutputFormatSchema = z.enum([
+  "human",
+  "json",
+  "markdown",
+  "sarif",
+]);
+export type OutputFormat = z.infer<typeof OutputFormatSchema>;
+
 export const CLIConfigSchema = z.object({
-  format: z.enum(["human", "json"]).default("human"),
+  format: OutputFormatSchema.default("human"),
   maxFiles: z.number().positive().default(100),
   extensions: z.array(z.string()).default(["ts", "tsx"]),
+  include: z.array(z.string()).default([]), // Globs for files without an extension
+  order: FileOrderSchema.default("default"),
+  seed: z.number().int().default(0), // For the random-seeded order
+  blame: z.boolean().default(false), // Annotate findings with git blame
 });
 
 export type CLIConfig = z.infer<typeof CLIConfigSchema>;
 
+export const PrivacyConfigSchema = z.object({
+  redactSecrets: z.boolean().default(true),
+  redactPatterns: z.array(z.string()).default([]),
+  // true: any remote provider, false: local only, array: only these providers
+  allowRemoteProviders: z
+    .union([z.boolean(), z.array(LLMProviderSchema)])
+    .default(true),
+  localOnlyPaths: z.array(z.string()).default([]),
+});
+
+export type PrivacyConfig = z.infer<typeof PrivacyConfigSchema>;
+
+export const AuditConfigSchema = z.object({
+  enabled: z.boolean().default(true),
+  path: z.string().optional(), // Default: ~/.local/state/lintai/audit.jsonl
+});
+
+export type AuditConfig = z.infer<typeof AuditConfigSchema>;
+
 export const AilintConfigSchema = z.object({
   llm: LLMConfigSchema.default({}),
   analysis: AnalysisConfigSchema.default({}),
@@ -116,6 +229,8 @@ export const AilintConfigSchema = z.object({
   severity: SeverityConfigSchema.default({}),
   performance: PerformanceConfigSchema.default({}),
   cli: CLIConfigSchema.default({}),
+  privacy: PrivacyConfigSchema.default({}),
+  audit: AuditConfigSchema.default({}),
   debug: z.boolean().default(false),
 });
 
@@ -135,3 +250,29 @@ export function resolveLLMConfig(config: LLMConfig): ResolvedLLMConfig {
     maxTokens: config.maxTokens ?? 2048,
   };
 }
+
+// Resolve the verifier LLM config. A different provider does not inherit the
+// analysis endpoint or key.
+export function resolveVerifierConfig(
+  llm: LLMConfig,
+  verify: VerifyConfig,
+): ResolvedLLMConfig {
+  const base = resolveLLMConfig(llm);
+  if (!verify.provider || verify.provider === base.provider) {
+    return {
+      ...base,
+      baseUrl: verify.baseUrl ?? base.baseUrl,
+      model: verify.model ?? base.model,
+      apiKey: verify.apiKey ?? base.apiKey,
+    };
+  }
+
+  const defaults = PROVIDER_DEFAULTS[verify.provider];
+  return {
+    ...base,
+    provider: verify.provider,
+    baseUrl: verify.baseUrl ?? defaults.baseUrl,
+    model: verify.model ?? defaults.model,
+    apiKey: verify.apiKey,
+  };
+}
@@ -1,5 +1,7 @@
 import { z } from "zod";
 
+// Lines and characters count from 0, as in LSP. The model reports lines
+// from 1; parseResponse converts them.
 export const RangeSchema = z.object({
   startLine: z.number().int().min(0),
   startCharacter: z.number().int().min(0),
@@ -15,6 +17,8 @@ export const FindingCategorySchema = z.enum([
   "spaghetti",
   "naming",
   "safety",
+  "untested", // Complex code without test coverage
+  "documentation", // Missing, stale or misleading comments
 ]);
 
 export type FindingCategory = z.infer<typeof FindingCategorySchema>;
@@ -28,6 +32,15 @@ export const FindingSeveritySchema = z.enum([
 
 export type FindingSeverity = z.infer<typeof FindingSeveritySchema>;
 
+// Who last changed the finding's lines and when, from git blame
+export const BlameInfoSchema = z.object({
+  author: z.string(),
+  lastModified: z.string(), // ISO date of the newest line in the range
+  ageDays: z.number().min(0),
+});
+
+export type BlameInfo = z.infer<typeof BlameInfoSchema>;
+
 export const FindingSchema = z.object({
   id: z.string(),
   title: z.string(),
@@ -37,6 +50,9 @@ export const FindingSchema = z.object({
   category: FindingCategorySchema,
   confidence: z.number().min(0).max(1),
   range: RangeSchema.optional(),
+  file: z.string().optional(), // Source file in multi-file analysis
+  cell: z.number().int().min(1).optional(), // Notebook cell; range is within it
+  blame: BlameInfoSchema.optional(),
 });
 
 export type Finding = z.infer<typeof FindingSchema>;
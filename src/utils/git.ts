@@ -0,0 +1,251 @@
+import { execFileSync } from "node:child_process";
+import { dirname, resolve } from "node:path";
+
+const MAX_BUFFER = 64 * 1024 * 1024;
+
+export interface RevisionFile {
+  path: string; // Absolute path in the work tree
+  commit: string; // Commit the version is from
+  blob: string; // Object id of the content
+}
+
+export interface BlameLine {
+  line: number; // 1-indexed
+  author: string;
+  time: number; // Author time, seconds since epoch
+}
+
+/**
+ * Run git and return stdout. Throws if git fails or is not installed.
+ */
+export function runGit(args: string[], cwd: string): string {
+  return execFileSync("git", args, {
+    cwd,
+    encoding: "utf-8",
+    maxBuffer: MAX_BUFFER,
+    stdio: ["ignore", "pipe", "ignore"],
+  });
+}
+
+/**
+ * Top-level directory of the repository containing dir, or null.
+ */
+export function getRepoRoot(dir: string): string | null {
+  try {
+    return runGit(["rev-parse", "--show-toplevel"], dir).trim();
+  } catch {
+    return null;
+  }
+}
+
+/**
+ * Full object id of the commit a revision names. Throws if there is none.
+ */
+export function resolveCommit(rev: string, cwd: string): string {
+  // --end-of-options keeps a revision like "--output=x" from being an option
+  return runGit(
+    ["rev-parse", "--verify", "--end-of-options", `${rev}^{commit}`],
+    cwd,
+  ).trim();
+}
+
+/**
+ * Files in the tree of a commit, limited to pathspecs relative to cwd.
+ */
+export function listTreeFiles(
+  repoRoot: string,
+  rev: string,
+  pathspecs: string[],
+  cwd: string,
+): RevisionFile[] {
+  const commit = resolveCommit(rev, cwd);
+  // -z keeps paths unquoted, e.g. with spaces or non-ASCII characters
+  const output = runGit(
+    ["ls-tree", "-r", "-z", "--full-name", commit, "--", ...pathspecs],
+    cwd,
+  );
+
+  const files: RevisionFile[] = [];
+  for (const row of output.split("\0")) {
+    // <mode> blob <id>\t<path>; submodules are commits and are skipped
+    const match = /^\d+ blob ([0-9a-f]+)\t([\s\S]+)$/.exec(row);
+    if (match) {
+      files.push({ path: resolve(repoRoot, match[2]), commit, blob: match[1] });
+    }
+  }
+  return files;
+}
+
+/**
+ * Every version of a file added or modified by the commits in a range like
+ * A..B, newest first. A file's version is listed once even if several
+ * commits produce the same content.
+ */
+export function listRangeFiles(
+  repoRoot: string,
+  range: string,
+  pathspecs: string[],
+  cwd: string,
+): RevisionFile[] {
+  const output = runGit(
+    [
+      "log",
+      "-z",
+      "--format=@%H",
+      "--raw",
+      "--no-abbrev",
+      "--no-renames",
+      "--diff-filter=AM",
+      "--end-of-options",
+      range,
+      "--",
+      ...pathspecs,
+    ],
+    cwd,
+  );
+
+  // With -z, fields are NUL-terminated: "@<commit>", then for each file
+  // ":<old mode> <new mode> <old id> <new id> <status>" and its path. The
+  // first field after a commit starts with a newline.
+  const fields = output.split("\0").map((field) => field.replace(/^\n/, ""));
+  const files: RevisionFile[] = [];
+  const seen = new Set<string>();
+  let commit = "";
+  for (let i = 0; i < fields.length; i++) {
+    if (fields[i].startsWith("@")) {
+      commit = fields[i].slice(1);
+      continue;
+    }
+    const match = /^:\d+ \d+ [0-9a-f]+ ([0-9a-f]+) ([A-Z])\d*$/.exec(
+      fields[i],
+    );
+    if (!match) {
+      continue;
+    }
+    // Always take the path, so it is never read as a field of its own
+    const path = fields[++i];
+    if (!"AM".includes(match[2]) || !path || seen.has(`${match[1]} ${path}`)) {
+      continue;
+    }
+    seen.add(`${match[1]} ${path}`);
+    files.push({ path: resolve(repoRoot, path), commit, blob: match[1] });
+  }
+  return files;
+}
+
+/**
+ * Content of a blob object.
+ */
+export function readBlob(blob: string, cwd: string): string {
+  return runGit(["cat-file", "blob", blob], cwd);
+}
+
+/**
+ * Number of commits touching each file in the last sinceDays days,
+ * keyed by absolute path.
+ */
+export function getFileChurn(
+  repoRoot: string,
+  sinceDays: number,
+): Map<string, number> {
+  // -z keeps paths unquoted, e.g. with spaces or non-ASCII characters
+  const output = runGit(
+    ["log", "-z", `--since=${sinceDays}.days`, "--format=", "--name-only"],
+    repoRoot,
+  );
+
+  const churn = new Map<string, number>();
+  for (const path of output.split("\0")) {
+    if (!path) continue;
+    const file = resolve(repoRoot, path);
+    churn.set(file, (churn.get(file) ?? 0) + 1);
+  }
+  return churn;
+}
+
+/**
+ * Time of the last commit touching each file, keyed by absolute path.
+ * Files with uncommitted changes count as modified now.
+ */
+export function getLastModified(repoRoot: string): Map<string, number> {
+  const output = runGit(
+    ["log", "-z", "--format=@%ct", "--name-only"],
+    repoRoot,
+  );
+
+  const modified = new Map<string, number>();
+  let time = 0;
+  // Entries are NUL-terminated; the first path of a commit follows a newline
+  for (const entry of output.split("\0")) {
+    const path = entry.replace(/^\n/, "");
+    if (path.startsWith("@")) {
+      time = Number(path.slice(1));
+    } else if (path) {
+      // Log is newest first, so the first time seen is the latest
+      const file = resolve(repoRoot, path);
+      if (!modified.has(file)) modified.set(file, time);
+    }
+  }
+
+  const now = Math.floor(Date.now() / 1000);
+  const status = runGit(
+    ["status", "--porcelain", "-z", "--no-renames"],
+    repoRoot,
+  );
+  for (const entry of status.split("\0")) {
+    if (entry.length > 3) {
+      modified.set(resolve(repoRoot, entry.slice(3)), now);
+    }
+  }
+
+  return modified;
+}
+
+/**
+ * Blame a line range of a file, in the work tree or as of a commit.
+ * Uncommitted lines are attributed to "Not Committed Yet" at the current
+ * time, as git reports them.
+ */
+export function blameRange(
+  filePath: string,
+  startLine: number,
+  endLine: number,
+  rev?: string,
+): BlameLine[] {
+  const output = runGit(
+    [
+      "blame",
+      "--porcelain",
+      "-L",
+      `${startLine},${endLine}`,
+      ...(rev ? [rev] : []),
+      "--",
+      filePath,
+    ],
+    dirname(filePath),
+  );
+
+  // Porcelain output repeats commit details only on a commit's first line
+  const commits = new Map<string, { author: string; time: number }>();
+  const lines: BlameLine[] = [];
+  let current: { sha: string; line: number } | null = null;
+
+  for (const row of output.split("\n")) {
+    const header = /^([0-9a-f]{40}) \d+ (\d+)/.exec(row);
+    if (header) {
+      current = { sha: header[1], line: Number(header[2]) };
+      if (!commits.has(current.sha)) {
+        commits.set(current.sha, { author: "", time: 0 });
+      }
+    } else if (current && row.startsWith("author ")) {
+      commits.get(current.sha)!.author = row.slice("author ".length);
+    } else if (current && row.startsWith("author-time ")) {
+      const time = Number(row.slice("author-time ".length));
+      commits.get(current.sha)!.time = time;
+    } else if (current && row.startsWith("\t")) {
+      lines.push({ line: current.line, ...commits.get(current.sha)! });
+    }
+  }
+
+  return lines;
+}
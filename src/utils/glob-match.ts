@@ -0,0 +1,103 @@
+/**
+ * Minimal gitignore-style glob matching for config path patterns.
+ *
+ * Supported syntax: `*`, `**`, `?`, `[abc]`, `{a,b}`.
+ * - A pattern without a slash matches at any depth (`*.pem`, `secrets`)
+ * - A leading slash anchors the pattern to the root (`/config/*.json`)
+ * - A pattern matching a directory matches everything below it, unless
+ *   matching with `directories: false`
+ */
+
+export interface GlobOptions {
+  directories?: boolean; // Match paths below a matching directory (default)
+}
+
+const regexCache = new Map<string, RegExp>();
+
+/**
+ * Convert a glob pattern to a regular expression matching relative paths.
+ */
+export function globToRegExp(
+  pattern: string,
+  options: GlobOptions = {},
+): RegExp {
+  const directories = options.directories ?? true;
+  const key = directories ? pattern : `file:${pattern}`;
+  const cached = regexCache.get(key);
+  if (cached) return cached;
+
+  let glob = pattern.replace(/\\/g, "/");
+  const anchored = glob.startsWith("/") || glob.slice(0, -1).includes("/");
+  glob = glob.replace(/^\//, "").replace(/\/$/, "");
+
+  let source = "";
+  let inGroup = false;
+
+  for (let i = 0; i < glob.length; i++) {
+    const char = glob[i];
+
+    if (char === "*") {
+      if (glob[i + 1] === "*") {
+        // "**/" matches zero or more directories, trailing "**" matches anything
+        if (glob[i + 2] === "/") {
+          source += "(?:.*/)?";
+          i += 2;
+        } else {
+          source += ".*";
+          i += 1;
+        }
+      } else {
+        source += "[^/]*";
+      }
+    } else if (char === "?") {
+      source += "[^/]";
+    } else if (char === "[") {
+      const end = glob.indexOf("]", i + 1);
+      if (end === -1) {
+        source += "\\[";
+      } else {
+        const body = glob.slice(i + 1, end).replace(/^!/, "^");
+        source += `[${body.replace(/\\/g, "\\\\")}]`;
+        i = end;
+      }
+    } else if (char === "{") {
+      inGroup = true;
+      source += "(?:";
+    } else if (char === "}" && inGroup) {
+      inGroup = false;
+      source += ")";
+    } else if (char === "," && inGroup) {
+      source += "|";
+    } else {
+      source += char.replace(/[.+^$()|\\]/g, "\\$&");
+    }
+  }
+
+  const prefix = anchored ? "^" : "^(?:.*/)?";
+  const suffix = directories ? "(?:/.*)?" : "";
+  const regex = new RegExp(`${prefix}${source}${suffix}$`);
+  regexCache.set(key, regex);
+  return regex;
+}
+
+/**
+ * Check whether a relative path matches a glob pattern.
+ */
+export function matchGlob(
+  relativePath: string,
+  pattern: string,
+  options?: GlobOptions,
+): boolean {
+  const normalized = relativePath.replace(/\\/g, "/").replace(/^\.\//, "");
+  return globToRegExp(pattern, options).test(normalized);
+}
+
+/**
+ * Return the first pattern that matches the path, or undefined.
+ */
+export function findMatchingGlob(
+  relativePath: string,
+  patterns: string[],
+): string | undefined {
+  return patterns.find((pattern) => matchGlob(relativePath, pattern));
+}
@@ -1,3 +1,6 @@
 export * from "./logger.js";
 export * from "./hash.js";
 export * from "./json-extract.js";
+export * from "./glob-match.js";
+export * from "./tokens.js";
+export * from "./git.js";
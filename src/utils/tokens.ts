@@ -0,0 +1,7 @@
+/**
+ * Rough token estimate for budgeting (about 4 characters per token).
+ * Good enough to keep prompts within limits without a tokenizer.
+ */
+export function estimateTokens(text: string): number {
+  return Math.ceil(text.length / 4);
+}
@@ -0,0 +1,211 @@
+import { describe, it, expect, beforeEach, afterEach } from "vitest";
+import { appendFileSync, mkdtempSync, readFileSync, rmSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import {
+  beginAuditEntry,
+  getDefaultAuditLogPath,
+  readAuditLog,
+  resolveAuditLogPath,
+  type AuditEntry,
+} from "../src/llm/audit-log.js";
+import { filterAuditEntries, summarizeAuditFiles } from "../src/cli/audit.js";
+import { DEFAULT_CONFIG } from "../src/config/defaults.js";
+import type { ResolvedLLMConfig } from "../src/types/config.js";
+
+const openai: ResolvedLLMConfig = {
+  provider: "openai",
+  baseUrl: "https://api.openai.com/v1",
+  model: "gpt-4o-mini",
+  timeout: 30000,
+  maxTokens: 2048,
+};
+
+const entry = (overrides: Partial<AuditEntry>): AuditEntry => ({
+  timestamp: "2026-03-01T12:00:00.000Z",
+  provider: "openai",
+  model: "gpt-4o-mini",
+  endpoint: "api.openai.com",
+  remote: true,
+  contentHash: "abc",
+  bytes: 10,
+  status: "ok",
+  user: "ada",
+  host: "ci",
+  pid: 1,
+  command: "lintai .",
+  ...overrides,
+});
+
+describe("audit log", () => {
+  let dir: string;
+  let logPath: string;
+
+  beforeEach(() => {
+    dir = mkdtempSync(join(tmpdir(), "lintai-audit-"));
+    logPath = join(dir, "state", "audit.jsonl");
+  });
+
+  afterEach(() => {
+    rmSync(dir, { recursive: true, force: true });
+  });
+
+  it("should be enabled by default", () => {
+    expect(resolveAuditLogPath(DEFAULT_CONFIG.audit)).toBe(
+      getDefaultAuditLogPath(),
+    );
+    expect(resolveAuditLogPath({ enabled: true, path: logPath })).toBe(
+      logPath,
+    );
+    expect(resolveAuditLogPath({ enabled: false })).toBeUndefined();
+  });
+
+  it("should append JSON lines per request", () => {
+    beginAuditEntry(logPath, {
+      config: openai,
+      content: "first",
+      filePath: "/repo/a.ts",
+    })({ status: "ok" });
+    const firstLines = readFileSync(logPath, "utf-8");
+    beginAuditEntry(logPath, {
+      config: openai,
+      content: "second",
+      filePath: "/repo/b.ts",
+    })({ usage: { promptTokens: 12, completionTokens: 3 }, status: "error" });
+
+    const text = readFileSync(logPath, "utf-8");
+    expect(text.startsWith(firstLines)).toBe(true);
+    expect(text.trimEnd().split("\n")).toHaveLength(4);
+
+    const entries = readAuditLog(logPath);
+    expect(entries[0]).toMatchObject({
+      endpoint: "api.openai.com",
+      remote: true,
+      filePath: "/repo/a.ts",
+      bytes: 5,
+      status: "ok",
+    });
+    expect(entries[1]).toMatchObject({ promptTokens: 12, status: "error" });
+    // Only a hash of the content is stored, never the content itself
+    expect(text).not.toContain("second");
+  });
+
+  it("should record a request before it completes", () => {
+    const finish = beginAuditEntry(logPath, {
+      config: openai,
+      content: "x",
+      filePath: "/repo/a.ts",
+    });
+
+    // A process killed here leaves the request in the log
+    expect(readAuditLog(logPath)).toMatchObject([
+      { filePath: "/repo/a.ts", status: "sent" },
+    ]);
+
+    finish({ usage: { promptTokens: 5, completionTokens: 1 }, status: "ok" });
+    expect(readAuditLog(logPath)).toMatchObject([
+      { filePath: "/repo/a.ts", promptTokens: 5, status: "ok" },
+    ]);
+  });
+
+  it("should skip a torn last line", () => {
+    beginAuditEntry(logPath, {
+      config: openai,
+      content: "x",
+      filePath: "/repo/a.ts",
+    })({ status: "ok" });
+    appendFileSync(logPath, '{"timestamp":"2026-03-0');
+
+    expect(readAuditLog(logPath)).toHaveLength(1);
+  });
+
+  it("should read a missing log as empty", () => {
+    expect(readAuditLog(logPath)).toEqual([]);
+  });
+});
+
+describe("filterAuditEntries", () => {
+  const entries = [
+    entry({ timestamp: "2026-03-01T00:00:00.000Z", filePath: "/repo/a.ts" }),
+    entry({
+      timestamp: "2026-03-05T00:00:00.000Z",
+      provider: "ollama",
+      remote: false,
+      filePath: "/repo/src/b.ts",
+    }),
+    entry({
+      timestamp: "2026-03-09T00:00:00.000Z",
+      filePath: "/repo/c.ts",
+      contextFiles: ["/repo/src/b.ts"],
+    }),
+  ];
+  const times = (filtered: AuditEntry[]) =>
+    filtered.map((e) => e.timestamp.slice(0, 10));
+
+  it("should filter by date range", () => {
+    const filtered = filterAuditEntries(
+      entries,
+      { since: "2026-03-02", until: "2026-03-06", remoteOnly: false },
+      "/repo",
+    );
+
+    expect(times(filtered)).toEqual(["2026-03-05"]);
+  });
+
+  it("should filter by provider and locality", () => {
+    expect(
+      times(
+        filterAuditEntries(
+          entries,
+          { provider: "ollama", remoteOnly: false },
+          "/repo",
+        ),
+      ),
+    ).toEqual(["2026-03-05"]);
+    expect(
+      times(filterAuditEntries(entries, { remoteOnly: true }, "/repo")),
+    ).toEqual(["2026-03-01", "2026-03-09"]);
+  });
+
+  it("should match files sent as context too", () => {
+    const filtered = filterAuditEntries(
+      entries,
+      { file: "src/**", remoteOnly: false },
+      "/repo",
+    );
+
+    expect(times(filtered)).toEqual(["2026-03-05", "2026-03-09"]);
+  });
+});
+
+describe("summarizeAuditFiles", () => {
+  it("should count requests per sent file", () => {
+    const summaries = summarizeAuditFiles([
+      entry({ timestamp: "2026-03-01T00:00:00.000Z", filePath: "/repo/a.ts" }),
+      entry({
+        timestamp: "2026-03-02T00:00:00.000Z",
+        provider: "anthropic",
+        model: "claude",
+        filePath: "/repo/b.ts",
+        contextFiles: ["/repo/a.ts"],
+      }),
+    ]);
+
+    expect(summaries).toEqual([
+      {
+        filePath: "/repo/a.ts",
+        requests: 2,
+        providers: ["openai/gpt-4o-mini", "anthropic/claude"],
+        firstSent: "2026-03-01T00:00:00.000Z",
+        lastSent: "2026-03-02T00:00:00.000Z",
+      },
+      {
+        filePath: "/repo/b.ts",
+        requests: 1,
+        providers: ["anthropic/claude"],
+        firstSent: "2026-03-02T00:00:00.000Z",
+        lastSent: "2026-03-02T00:00:00.000Z",
+      },
+    ]);
+  });
+});
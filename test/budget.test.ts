@@ -0,0 +1,56 @@
+import { describe, it, expect } from "vitest";
+import { parseDuration } from "../src/cli/budget.js";
+import { formatJSON, formatMarkdown } from "../src/cli/formatter.js";
+import { mergeReports } from "../src/cli/merge.js";
+import type { AnalysisResult } from "../src/core/analyzer.js";
+
+describe("parseDuration", () => {
+  it("should parse units and combinations", () => {
+    expect(parseDuration("90s")).toBe(90_000);
+    expect(parseDuration("10m")).toBe(600_000);
+    expect(parseDuration("1h30m")).toBe(5_400_000);
+    expect(parseDuration("1.5h")).toBe(5_400_000);
+    expect(parseDuration("500ms")).toBe(500);
+    expect(parseDuration("45")).toBe(45_000);
+  });
+
+  it("should reject invalid durations", () => {
+    expect(() => parseDuration("10 minutes")).toThrow(/Invalid duration/);
+    expect(() => parseDuration("m")).toThrow(/Invalid duration/);
+  });
+});
+
+describe("stopped runs", () => {
+  const results = new Map<string, AnalysisResult>([
+    ["/repo/a.ts", { findings: [], cached: false }],
+  ]);
+  const stopped = {
+    reason: "time-budget" as const,
+    skipped: ["/repo/b.ts", "/repo/c.ts"],
+  };
+
+  it("should mark reports incomplete and list skipped files", () => {
+    const json = JSON.parse(formatJSON(results, { stopped }));
+
+    expect(json).toMatchObject({
+      incomplete: true,
+      stopReason: "time-budget",
+      skipped: ["/repo/b.ts", "/repo/c.ts"],
+    });
+    expect(formatMarkdown(results, { rootDir: "/repo", stopped })).toContain(
+      "> **Incomplete:** the time budget ran out; 2 files were not analyzed.",
+    );
+  });
+
+  it("should drop skipped files another shard analyzed when merging", () => {
+    const { merged } = mergeReports([
+      JSON.parse(formatJSON(results, { stopped })),
+      JSON.parse(
+        formatJSON(new Map([["/repo/b.ts", { findings: [], cached: false }]])),
+      ),
+    ]);
+
+    expect(merged.incomplete).toBe(true);
+    expect(merged.skipped).toEqual(["/repo/c.ts"]);
+  });
+});
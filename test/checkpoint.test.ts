@@ -0,0 +1,91 @@
+import { describe, it, expect, beforeEach, afterEach } from "vitest";
+import { appendFileSync, mkdtempSync, readFileSync, rmSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import {
+  checkpointPath,
+  loadCheckpoint,
+  recordCheckpoint,
+  startCheckpoint,
+  type CheckpointEntry,
+} from "../src/cli/checkpoint.js";
+import { createRedactor } from "../src/core/redactor.js";
+
+const entry = (path: string): CheckpointEntry => ({
+  path,
+  contentHash: "abc",
+  result: { findings: [], cached: false },
+});
+
+describe("checkpoint", () => {
+  let root: string;
+  let path: string;
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), "lintai-checkpoint-"));
+    path = checkpointPath(root);
+  });
+
+  afterEach(() => {
+    rmSync(root, { recursive: true, force: true });
+  });
+
+  it("should keep resumed entries and add recorded ones", () => {
+    startCheckpoint(path, "config1", [entry("/a.ts")]);
+    recordCheckpoint(path, entry("/b.ts"));
+
+    expect([...loadCheckpoint(path, "config1").keys()]).toEqual([
+      "/a.ts",
+      "/b.ts",
+    ]);
+  });
+
+  it("should ignore a line cut off by an interrupted write", () => {
+    startCheckpoint(path, "config1");
+    recordCheckpoint(path, entry("/a.ts"));
+    appendFileSync(path, '{"path":"/b.ts","conte');
+
+    expect([...loadCheckpoint(path, "config1").keys()]).toEqual(["/a.ts"]);
+  });
+
+  it("should ignore a checkpoint written with another config", () => {
+    startCheckpoint(path, "config1", [entry("/a.ts")]);
+
+    expect(loadCheckpoint(path, "config2").size).toBe(0);
+  });
+
+  it("should not write restored secrets to disk", () => {
+    const key = "sk-abcdefghijklmnopqrstuvwxyz123456";
+    const redactor = createRedactor({
+      redactSecrets: true,
+      redactPatterns: [],
+    });
+    const finding = {
+      id: "AI001",
+      title: "Hardcoded key",
+      severity: "error" as const,
+      message: `The key ${key} is committed`,
+      suggestion: `Load ${key} from the environment`,
+      category: "safety" as const,
+      confidence: 0.9,
+    };
+
+    startCheckpoint(path, "config1");
+    recordCheckpoint(
+      path,
+      { ...entry("/a.ts"), result: { findings: [finding], cached: false } },
+      redactor,
+    );
+
+    expect(readFileSync(path, "utf-8")).not.toContain(key);
+    expect(
+      loadCheckpoint(path, "config1").get("/a.ts")?.result.findings[0].message,
+    ).toBe("The key REDACTED_API_KEY_1 is committed");
+  });
+
+  it("should use a separate file per shard", () => {
+    expect(checkpointPath(root, { index: 2, count: 4 })).toBe(
+      join(root, ".lintai", "checkpoint-2of4.jsonl"),
+    );
+  });
+});
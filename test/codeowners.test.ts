@@ -0,0 +1,126 @@
+import { describe, it, expect } from "vitest";
+import {
+  isOwnedBy,
+  ownersOf,
+  parseCodeowners,
+} from "../src/core/codeowners.js";
+import { formatMarkdown, groupByOwner, UNOWNED } from "../src/cli/formatter.js";
+import { formatSARIF } from "../src/cli/sarif.js";
+import type { AnalysisResult } from "../src/core/analyzer.js";
+
+const codeowners = `# Default owners
+*                   @org/platform
+/services/billing/  @org/billing @alice
+*.md                @org/docs
+/services/billing/README.md
+
+[Frontend]
+apps/web/**/*.tsx   @org/web
+`;
+
+const result = (severity: "error" | "warning"): AnalysisResult => ({
+  cached: false,
+  findings: [
+    {
+      id: "AI001",
+      title: "Swallowed error",
+      severity,
+      message: "The error is ignored.",
+      suggestion: "Return it.",
+      category: "safety",
+      confidence: 0.9,
+      range: { startLine: 4, startCharacter: 2, endLine: 4, endCharacter: 9 },
+    },
+  ],
+});
+
+describe("parseCodeowners", () => {
+  it("should parse rules and skip comments and sections", () => {
+    expect(parseCodeowners(codeowners)).toEqual([
+      { pattern: "*", owners: ["@org/platform"] },
+      { pattern: "/services/billing/", owners: ["@org/billing", "@alice"] },
+      { pattern: "*.md", owners: ["@org/docs"] },
+      { pattern: "/services/billing/README.md", owners: [] },
+      { pattern: "apps/web/**/*.tsx", owners: ["@org/web"] },
+    ]);
+  });
+});
+
+describe("ownersOf", () => {
+  const rules = parseCodeowners(codeowners);
+  const owners = (path: string) => ownersOf(rules, `/repo/${path}`, "/repo");
+
+  it("should let the last matching rule win", () => {
+    expect(owners("services/billing/invoice.go")).toEqual([
+      "@org/billing",
+      "@alice",
+    ]);
+    expect(owners("services/auth/login.go")).toEqual(["@org/platform"]);
+    expect(owners("apps/web/src/pages/Home.tsx")).toEqual(["@org/web"]);
+    expect(owners("docs/setup.md")).toEqual(["@org/docs"]);
+    expect(owners("services/billing/README.md")).toEqual([]);
+  });
+
+  it("should match owners case-insensitively with optional @", () => {
+    expect(isOwnedBy(["@org/Billing"], ["org/billing"])).toBe(true);
+    expect(isOwnedBy(["@org/billing"], ["@org/web"])).toBe(false);
+  });
+});
+
+describe("owner reports", () => {
+  const results = new Map([
+    ["/repo/services/billing/invoice.go", result("error")],
+    ["/repo/services/billing/README.md", result("warning")],
+    ["/repo/main.go", { findings: [], cached: false }],
+  ]);
+  const owners = new Map([
+    ["/repo/services/billing/invoice.go", ["@org/billing", "@alice"]],
+    ["/repo/services/billing/README.md", []],
+    ["/repo/main.go", ["@org/platform"]],
+  ]);
+
+  it("should group files under each owner, unowned last", () => {
+    const groups = groupByOwner(results, owners);
+
+    expect([...groups.keys()]).toEqual([
+      "@alice",
+      "@org/billing",
+      "@org/platform",
+      UNOWNED,
+    ]);
+    expect([...groups.get(UNOWNED)!.keys()]).toEqual([
+      "/repo/services/billing/README.md",
+    ]);
+  });
+
+  it("should write a markdown report by owner", () => {
+    const markdown = formatMarkdown(results, {
+      rootDir: "/repo",
+      groups: groupByOwner(results, owners),
+    });
+
+    expect(markdown).toContain(
+      "3 files analyzed, 2 issues (1 error, 1 warning)",
+    );
+    expect(markdown).toContain(
+      "## @org/billing\n\n### `services/billing/invoice.go`\n\n" +
+        "- **error** `services/billing/invoice.go:5` Swallowed error (Type Safety)",
+    );
+    // Files without findings are left out
+    expect(markdown).not.toContain("@org/platform");
+  });
+
+  it("should attach owners to SARIF results", () => {
+    const log = JSON.parse(
+      formatSARIF(results, { rootDir: "/repo", owners }),
+    );
+    const [first] = log.runs[0].results;
+
+    expect(first.ruleId).toBe("safety");
+    expect(first.properties["lintai/owners"]).toEqual([
+      "@org/billing",
+      "@alice",
+    ]);
+    expect(first.locations[0].physicalLocation.region.startLine).toBe(5);
+  });
+});
@@ -0,0 +1,55 @@
+import { describe, it, expect, beforeEach, afterEach } from "vitest";
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { loadConfig } from "../src/config/loader.js";
+
+describe("loadConfig locked settings", () => {
+  let home: string;
+  let project: string;
+  const originalHome = process.env["HOME"];
+
+  const writeJSON = (path: string, value: unknown) => {
+    mkdirSync(join(path, ".."), { recursive: true });
+    writeFileSync(path, JSON.stringify(value));
+  };
+
+  beforeEach(() => {
+    home = mkdtempSync(join(tmpdir(), "lintai-home-"));
+    project = mkdtempSync(join(tmpdir(), "lintai-project-"));
+    process.env["HOME"] = home;
+  });
+
+  afterEach(() => {
+    process.env["HOME"] = originalHome;
+    rmSync(home, { recursive: true, force: true });
+    rmSync(project, { recursive: true, force: true });
+  });
+
+  it("should keep a locked org setting over the project config", () => {
+    writeJSON(join(home, ".config", "lintai", "lintai.json"), {
+      privacy: { allowRemoteProviders: false },
+      locked: ["privacy.allowRemoteProviders"],
+    });
+    writeJSON(join(project, "lintai.json"), {
+      privacy: { allowRemoteProviders: true, localOnlyPaths: ["secrets/**"] },
+    });
+
+    const config = loadConfig(project);
+
+    expect(config.privacy.allowRemoteProviders).toBe(false);
+    // Settings that are not locked still come from the project
+    expect(config.privacy.localOnlyPaths).toEqual(["secrets/**"]);
+  });
+
+  it("should let the project override settings that are not locked", () => {
+    writeJSON(join(home, ".config", "lintai", "lintai.json"), {
+      privacy: { allowRemoteProviders: false },
+    });
+    writeJSON(join(project, "lintai.json"), {
+      privacy: { allowRemoteProviders: true },
+    });
+
+    expect(loadConfig(project).privacy.allowRemoteProviders).toBe(true);
+  });
+});
@@ -0,0 +1,121 @@
+import { describe, it, expect } from "vitest";
+import {
+  coverageRatio,
+  findFileCoverage,
+  findUntestedFunctions,
+  orderByUncovered,
+  parseCoverageReport,
+} from "../src/core/coverage.js";
+
+const goSource = `package store
+
+func Get(key string) string {
+	return cache[key]
+}
+
+func Put(key, value string) error {
+	if key == "" {
+		return errEmpty
+	}
+	cache[key] = value
+	return nil
+}
+`;
+
+const goProfile = `mode: set
+example.com/app/store/store.go:3.29,5.2 1 1
+example.com/app/store/store.go:7.37,8.14 1 0
+example.com/app/store/store.go:8.14,10.3 1 0
+example.com/app/store/store.go:11.2,12.12 2 0
+example.com/app/store/other.go:3.10,4.2 1 1
+`;
+
+describe("parseCoverageReport", () => {
+  it("should parse Go cover profiles", () => {
+    const report = parseCoverageReport(goProfile);
+    const store = report.get("example.com/app/store/store.go")!;
+
+    expect(store.lines.get(4)).toBe(1);
+    expect(store.lines.get(9)).toBe(0);
+    expect(coverageRatio(store)).toBeCloseTo(3 / 9);
+  });
+
+  it("should parse lcov with function data", () => {
+    const report = parseCoverageReport(`TN:
+SF:src/math.ts
+FN:1,add
+FN:5,divide
+FNDA:3,add
+FNDA:0,divide
+DA:2,3
+DA:6,0
+DA:7,0
+end_of_record
+`);
+    const math = report.get("src/math.ts")!;
+
+    expect(math.lines.size).toBe(3);
+    expect(math.functions).toEqual([
+      { name: "add", startLine: 1, hits: 3 },
+      { name: "divide", startLine: 5, hits: 0 },
+    ]);
+  });
+
+  it("should parse Cobertura XML", () => {
+    const report = parseCoverageReport(`<?xml version="1.0" ?>
+<coverage><packages><package name="app"><classes>
+  <class name="svc" filename="app/svc.py">
+    <methods>
+      <method name="run" signature="()"><lines>
+        <line number="4" hits="0"/><line number="5" hits="0"/>
+      </lines></method>
+    </methods>
+    <lines>
+      <line number="1" hits="1"/><line number="4" hits="0"/>
+      <line number="5" hits="0"/>
+    </lines>
+  </class>
+</classes></package></packages></coverage>`);
+    const svc = report.get("app/svc.py")!;
+
+    expect(svc.lines.size).toBe(3);
+    expect(svc.functions).toEqual([{ name: "run", startLine: 4, hits: 0 }]);
+  });
+
+  it("should reject unknown formats", () => {
+    expect(() => parseCoverageReport("hello")).toThrow(/Unrecognized/);
+  });
+});
+
+describe("findUntestedFunctions", () => {
+  it("should find functions none of whose lines ran", () => {
+    const report = parseCoverageReport(goProfile);
+    const coverage = findFileCoverage("store/store.go", [report], "/repo")!;
+
+    expect(findUntestedFunctions(goSource, "go", coverage)).toEqual([
+      {
+        name: "func Put(key, value string) error",
+        startLine: 7,
+        endLine: 14,
+      },
+    ]);
+  });
+});
+
+describe("orderByUncovered", () => {
+  it("should put files with the most untested lines first", () => {
+    const report = parseCoverageReport(goProfile);
+
+    expect(
+      orderByUncovered(
+        ["/repo/main.go", "/repo/store/other.go", "/repo/store/store.go"],
+        [report],
+        "/repo",
+      ),
+    ).toEqual([
+      "/repo/store/store.go",
+      "/repo/store/other.go",
+      "/repo/main.go",
+    ]);
+  });
+});
@@ -4,6 +4,7 @@ import {
   findingToDiagnostic,
   findingsToDiagnostics,
   createErrorDiagnostic,
+  categoryToString,
 } from "../src/core/diagnostics-mapper.js";
 import type { Finding } from "../src/types/finding.js";
 
@@ -171,3 +172,14 @@ describe("createErrorDiagnostic", () => {
     expect(diagnostic.severity).toBe(DiagnosticSeverity.Error);
   });
 });
+
+describe("categoryToString", () => {
+  it("should name known categories", () => {
+    expect(categoryToString("documentation")).toBe("Documentation");
+    expect(categoryToString("smell")).toBe("Code Smell");
+  });
+
+  it("should pass unknown categories through", () => {
+    expect(categoryToString("other")).toBe("other");
+  });
+});
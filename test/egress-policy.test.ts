@@ -0,0 +1,113 @@
+import { describe, it, expect } from "vitest";
+import {
+  checkEgressPolicy,
+  isLocalProvider,
+} from "../src/core/egress-policy.js";
+import type { PrivacyConfig, ResolvedLLMConfig } from "../src/types/config.js";
+
+const openai: ResolvedLLMConfig = {
+  provider: "openai",
+  baseUrl: "https://api.openai.com/v1",
+  model: "gpt-4o-mini",
+  timeout: 30000,
+  maxTokens: 2048,
+};
+
+const ollama: ResolvedLLMConfig = {
+  provider: "ollama",
+  baseUrl: "http://localhost:11434",
+  model: "codellama",
+  timeout: 30000,
+  maxTokens: 2048,
+};
+
+function privacy(overrides: Partial<PrivacyConfig> = {}): PrivacyConfig {
+  return {
+    redactSecrets: true,
+    redactPatterns: [],
+    allowRemoteProviders: true,
+    localOnlyPaths: [],
+    ...overrides,
+  };
+}
+
+describe("isLocalProvider", () => {
+  it("should treat loopback endpoints as local", () => {
+    expect(isLocalProvider(ollama)).toBe(true);
+    expect(
+      isLocalProvider({ ...openai, baseUrl: "http://127.0.0.1:1234/v1" }),
+    ).toBe(true);
+  });
+
+  it("should treat a remote ollama server as remote", () => {
+    expect(
+      isLocalProvider({ ...ollama, baseUrl: "http://gpu-box.corp:11434" }),
+    ).toBe(false);
+  });
+});
+
+describe("checkEgressPolicy", () => {
+  const root = "/repo";
+
+  it("should allow remote providers by default", () => {
+    expect(
+      checkEgressPolicy("/repo/src/a.ts", openai, privacy(), root),
+    ).toBeNull();
+  });
+
+  it("should refuse remote providers when disallowed", () => {
+    const reason = checkEgressPolicy(
+      "/repo/src/a.ts",
+      openai,
+      privacy({ allowRemoteProviders: false }),
+      root,
+    );
+
+    expect(reason).toContain("src/a.ts");
+    expect(reason).toContain("allowRemoteProviders");
+  });
+
+  it("should honor a provider allowlist", () => {
+    const policy = privacy({ allowRemoteProviders: ["anthropic"] });
+
+    expect(checkEgressPolicy("/repo/a.ts", openai, policy, root)).toContain(
+      "allowRemoteProviders",
+    );
+    expect(
+      checkEgressPolicy(
+        "/repo/a.ts",
+        {
+          ...openai,
+          provider: "anthropic",
+          baseUrl: "https://api.anthropic.com",
+        },
+        policy,
+        root,
+      ),
+    ).toBeNull();
+  });
+
+  it("should keep local-only paths away from remote providers", () => {
+    const policy = privacy({ localOnlyPaths: ["services/billing", "*.pem"] });
+
+    expect(
+      checkEgressPolicy(
+        "/repo/services/billing/charge.go",
+        openai,
+        policy,
+        root,
+      ),
+    ).toContain('"services/billing"');
+    expect(
+      checkEgressPolicy("/repo/services/api/main.go", openai, policy, root),
+    ).toBeNull();
+    expect(
+      checkEgressPolicy(
+        "/repo/services/billing/charge.go",
+        ollama,
+        policy,
+        root,
+      ),
+    ).toBeNull();
+  });
+});
@@ -0,0 +1,75 @@
+import { describe, it, expect } from "vitest";
+import { chunkByFunction } from "../src/core/chunker.js";
+import {
+  cosineSimilarity,
+  rankChunks,
+  type IndexedChunk,
+} from "../src/core/embedding-index.js";
+
+describe("chunkByFunction", () => {
+  it("should split Go files at top-level definitions", () => {
+    const content = [
+      "package main",
+      "",
+      "// Add returns the sum",
+      "func Add(a, b int) int {",
+      "\treturn a + b",
+      "}",
+      "",
+      "type Point struct {",
+      "\tX, Y int",
+      "}",
+    ].join("\n");
+
+    const chunks = chunkByFunction(content, "go");
+
+    expect(chunks.map((c) => [c.startLine, c.endLine])).toEqual([
+      [1, 2],
+      [3, 7],
+      [8, 10],
+    ]);
+    expect(chunks[1].text).toContain("// Add returns the sum");
+  });
+
+  it("should fall back to fixed windows without definitions", () => {
+    const content = Array.from({ length: 130 }, (_, i) => `x${i} = ${i}`).join(
+      "\n",
+    );
+
+    const chunks = chunkByFunction(content);
+
+    expect(chunks).toHaveLength(3);
+    expect(chunks[2].startLine).toBe(121);
+    expect(chunks[2].endLine).toBe(130);
+  });
+});
+
+describe("rankChunks", () => {
+  const chunk = (file: string, vector: number[]): IndexedChunk => ({
+    file,
+    startLine: 1,
+    endLine: 1,
+    hash: "",
+    vector,
+  });
+
+  it("should compute cosine similarity", () => {
+    expect(cosineSimilarity([1, 0], [1, 0])).toBeCloseTo(1);
+    expect(cosineSimilarity([1, 0], [0, 1])).toBeCloseTo(0);
+    expect(cosineSimilarity([1, 0], [1, 0, 0])).toBe(0);
+  });
+
+  it("should rank by best match and exclude the analyzed file", () => {
+    const ranked = rankChunks(
+      [
+        chunk("a.go", [0, 1]),
+        chunk("b.go", [1, 0]),
+        chunk("self.go", [1, 0]),
+      ],
+      [[1, 0.1]],
+      "self.go",
+    );
+
+    expect(ranked.map((r) => r.chunk.file)).toEqual(["b.go", "a.go"]);
+  });
+});
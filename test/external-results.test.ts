@@ -0,0 +1,225 @@
+import { describe, it, expect } from "vitest";
+import {
+  dropDuplicateFindings,
+  parseExternalResults,
+  type ExternalDiagnostic,
+} from "../src/core/external-results.js";
+import type { Finding } from "../src/types/finding.js";
+
+const root = "/repo";
+
+function finding(overrides: Partial<Finding>): Finding {
+  return {
+    id: "AI001",
+    title: "Issue",
+    severity: "warning",
+    message: "Something is wrong",
+    suggestion: "Fix it",
+    category: "practice",
+    confidence: 0.8,
+    // Line 10 of the file
+    range: { startLine: 9, startCharacter: 0, endLine: 9, endCharacter: 1 },
+    ...overrides,
+  };
+}
+
+describe("parseExternalResults", () => {
+  it("should parse SARIF results", () => {
+    const sarif = JSON.stringify({
+      version: "2.1.0",
+      runs: [
+        {
+          tool: { driver: { name: "semgrep" } },
+          results: [
+            {
+              ruleId: "sql-injection",
+              level: "error",
+              message: { text: "Query built from user input" },
+              locations: [
+                {
+                  physicalLocation: {
+                    artifactLocation: { uri: "src/db.ts" },
+                    region: { startLine: 4, endLine: 6, startColumn: 3 },
+                  },
+                },
+              ],
+            },
+          ],
+        },
+      ],
+    });
+
+    expect(parseExternalResults(sarif, root)).toEqual([
+      {
+        file: "/repo/src/db.ts",
+        line: 4,
+        endLine: 6,
+        column: 3,
+        rule: "sql-injection",
+        message: "Query built from user input",
+        source: "semgrep",
+        level: "error",
+      },
+    ]);
+  });
+
+  it("should parse eslint, golangci-lint and tsc output", () => {
+    const eslint = JSON.stringify([
+      {
+        filePath: "/repo/src/a.ts",
+        messages: [
+          { ruleId: "no-empty", severity: 2, message: "Empty block", line: 3 },
+        ],
+      },
+    ]);
+    const golangci = JSON.stringify({
+      Issues: [
+        {
+          FromLinter: "errcheck",
+          Text: "Error return value of `f.Close` is not checked",
+          Pos: { Filename: "cmd/main.go", Line: 12, Column: 2 },
+        },
+      ],
+    });
+    const tsc = [
+      "src/b.ts(7,5): error TS2322: Type 'string' is not assignable to type 'number'.",
+      "src/c.ts:2:1 - error TS7006: Parameter 'x' implicitly has an 'any' type.",
+    ].join("\n");
+
+    expect(parseExternalResults(eslint, root)[0]).toMatchObject({
+      file: "/repo/src/a.ts",
+      line: 3,
+      rule: "no-empty",
+      source: "eslint",
+      level: "error",
+    });
+    expect(parseExternalResults(golangci, root)[0]).toMatchObject({
+      file: "/repo/cmd/main.go",
+      line: 12,
+      rule: "errcheck",
+      source: "golangci-lint",
+    });
+    expect(parseExternalResults(tsc, root)).toMatchObject([
+      { file: "/repo/src/b.ts", line: 7, column: 5, rule: "TS2322" },
+      { file: "/repo/src/c.ts", line: 2, column: 1, rule: "TS7006" },
+    ]);
+  });
+
+  it("should reject unknown JSON formats", () => {
+    expect(() => parseExternalResults('{"foo": 1}', root)).toThrow(
+      /Unrecognized results format/,
+    );
+  });
+});
+
+describe("dropDuplicateFindings", () => {
+  const errcheck: ExternalDiagnostic = {
+    file: "/repo/main.go",
+    line: 10,
+    rule: "errcheck",
+    message: "Error return value of `f.Close` is not checked",
+    source: "golangci-lint",
+  };
+
+  it("should drop findings on the same lines about the same issue", () => {
+    const findings = [
+      finding({ title: "Unchecked error from Close" }),
+      finding({
+        id: "AI002",
+        title: "Magic number",
+        message: "Use a named constant for the retry count",
+      }),
+      finding({
+        id: "AI003",
+        title: "Ignored error",
+        range: {
+          startLine: 40,
+          startCharacter: 0,
+          endLine: 40,
+          endCharacter: 1,
+        },
+      }),
+    ];
+
+    const result = dropDuplicateFindings(findings, [errcheck]);
+
+    expect(result.dropped).toBe(1);
+    expect(result.findings.map((f) => f.id)).toEqual(["AI002", "AI003"]);
+  });
+
+  it("should keep findings that only mention errors in passing", () => {
+    const findings = [
+      finding({
+        title: "Long function",
+        message: "This function handles the error path and the retry loop",
+      }),
+      finding({ id: "AI002", title: "Error message is not actionable" }),
+    ];
+
+    const result = dropDuplicateFindings(findings, [errcheck]);
+
+    expect(result.dropped).toBe(0);
+  });
+
+  it("should match errcheck phrasing in any word form", () => {
+    const findings = [
+      finding({ title: "Error not checked", message: "Close can fail" }),
+      finding({ id: "AI002", title: "Unhandled errors from Close" }),
+    ];
+
+    expect(dropDuplicateFindings(findings, [errcheck]).dropped).toBe(2);
+  });
+
+  it("should keep findings that only share a rule's common words", () => {
+    const diagnostics: ExternalDiagnostic[] = [
+      { ...errcheck, rule: "gosec", message: "G104: Errors unhandled" },
+      { ...errcheck, rule: "no-explicit-any", message: "Unexpected any" },
+      { ...errcheck, rule: "no-empty", message: "Empty block statement" },
+    ];
+    const findings = [
+      finding({
+        title: "Missing bounds check",
+        message: "Any caller can pass a negative size, a security risk",
+      }),
+      finding({ id: "AI002", title: "Retry loop is empty-handed on timeout" }),
+    ];
+
+    expect(dropDuplicateFindings(findings, diagnostics).dropped).toBe(0);
+    expect(
+      dropDuplicateFindings(
+        [finding({ title: "Avoid the any type for the config" })],
+        diagnostics,
+      ).dropped,
+    ).toBe(1);
+  });
+
+  it("should compare lines in the same base as the diagnostic", () => {
+    const unchecked = finding({ title: "Unchecked error from Close" });
+
+    // Line 10 is two lines below the diagnostic on line 8
+    expect(
+      dropDuplicateFindings([unchecked], [{ ...errcheck, line: 8 }]).dropped,
+    ).toBe(0);
+    expect(
+      dropDuplicateFindings([unchecked], [{ ...errcheck, line: 11 }]).dropped,
+    ).toBe(1);
+  });
+
+  it("should match on message similarity without a known rule", () => {
+    const diagnostic: ExternalDiagnostic = {
+      file: "/repo/a.ts",
+      line: 11,
+      rule: "custom/no-sync-fs",
+      message: "Synchronous filesystem call blocks the event loop",
+      source: "eslint",
+    };
+    const findings = [
+      finding({
+        title: "Blocking filesystem call",
+        message: "readFileSync blocks the event loop in a request handler",
+      }),
+    ];
+
+    expect(dropDuplicateFindings(findings, [diagnostic]).dropped).toBe(1);
+  });
+});
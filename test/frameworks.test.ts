@@ -0,0 +1,54 @@
+import { describe, it, expect, beforeAll, afterAll } from "vitest";
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { join } from "node:path";
+import { tmpdir } from "node:os";
+import { detectFrameworks } from "../src/core/frameworks.js";
+
+describe("detectFrameworks", () => {
+  let root: string;
+
+  beforeAll(() => {
+    root = mkdtempSync(join(tmpdir(), "lintai-frameworks-"));
+    mkdirSync(join(root, "web"), { recursive: true });
+    mkdirSync(join(root, "api"), { recursive: true });
+    writeFileSync(
+      join(root, "web", "package.json"),
+      JSON.stringify({
+        dependencies: { express: "^4.19.0", "react-dom": "^18.0.0" },
+      }),
+    );
+    writeFileSync(
+      join(root, "api", "go.mod"),
+      "module api\n\ngo 1.22\n\nrequire github.com/labstack/echo/v4 v4.11.4\n",
+    );
+  });
+
+  afterAll(() => {
+    rmSync(root, { recursive: true, force: true });
+  });
+
+  const ids = (filePath: string, content: string, languageId: string) =>
+    detectFrameworks(join(root, filePath), content, languageId, root).map(
+      (f) => f.id,
+    );
+
+  it("should detect frameworks from package.json dependencies", () => {
+    expect(ids("web/server.ts", "", "typescript")).toEqual(["express"]);
+  });
+
+  it("should detect frameworks from imports", () => {
+    expect(
+      ids("web/App.tsx", 'import { useState } from "react";', "typescript"),
+    ).toEqual(["react", "express"]);
+  });
+
+  it("should only apply component guidance to component files", () => {
+    expect(
+      ids("web/util.ts", 'import React from "react";', "typescript"),
+    ).toEqual(["express"]);
+  });
+
+  it("should detect Go modules from go.mod", () => {
+    expect(ids("api/handler.go", "package api", "go")).toEqual(["echo"]);
+  });
+});
@@ -0,0 +1,125 @@
+import { describe, it, expect, beforeAll, afterAll } from "vitest";
+import { execFileSync } from "node:child_process";
+import { mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { orderFiles } from "../src/cli/ordering.js";
+import { annotateBlame, filterByAge } from "../src/cli/blame.js";
+import type { Finding } from "../src/types/finding.js";
+
+let repo: string;
+
+function git(args: string[], env: Record<string, string> = {}) {
+  execFileSync(
+    "git",
+    ["-c", "user.name=Ada", "-c", "user.email=ada@example.com", ...args],
+    { cwd: repo, stdio: "ignore", env: { ...process.env, ...env } },
+  );
+}
+
+function commit(file: string, content: string, date: string) {
+  writeFileSync(join(repo, file), content);
+  git(["add", file]);
+  git(["commit", "-q", "-m", `update ${file}`, `--date=${date}`], {
+    GIT_COMMITTER_DATE: date,
+  });
+}
+
+beforeAll(() => {
+  repo = mkdtempSync(join(tmpdir(), "lintai-git-"));
+  git(["init", "-q"]);
+  const recent = new Date(Date.now() - 2 * 86400000).toISOString();
+  commit("old.ts", "export const a = 1;\n", "2020-01-01T00:00:00Z");
+  commit("pair.ts", "export const d = 1;\n", "2020-01-01T00:00:00Z");
+  writeFileSync(join(repo, "pair.ts"), "export const d = 1;\nconst e = 2;\n");
+  git(["commit", "-qam", "add e", "--date=2021-01-01T00:00:00Z"], {
+    GIT_AUTHOR_NAME: "Grace",
+  });
+  // Paths git quotes by default
+  commit("spaced näme.ts", "export const f = 1;\n", "2021-01-01T00:00:00Z");
+  commit("spaced näme.ts", "export const f = 2;\n", "2021-01-01T00:00:00Z");
+  commit("hot.ts", "export const b = 1;\n", recent);
+  commit("hot.ts", "export const b = 2;\n", recent);
+  commit("hot.ts", "export const b = 3;\nexport const c = 4;\n", recent);
+});
+
+afterAll(() => {
+  rmSync(repo, { recursive: true, force: true });
+});
+
+const finding = (startLine: number): Finding => ({
+  id: "AI001",
+  title: "Issue",
+  severity: "warning",
+  message: "Something",
+  suggestion: "Fix it",
+  category: "practice",
+  confidence: 0.8,
+  range: { startLine, startCharacter: 0, endLine: startLine, endCharacter: 1 },
+});
+
+describe("orderFiles", () => {
+  it("should order paths that git quotes", () => {
+    const spaced = join(repo, "spaced näme.ts");
+    const files = [join(repo, "old.ts"), spaced];
+
+    expect(orderFiles(files, "recent", { cwd: repo, seed: 0 })[0]).toBe(spaced);
+  });
+
+  it("should put frequently and recently changed files first", () => {
+    const files = [join(repo, "old.ts"), join(repo, "hot.ts")];
+
+    expect(orderFiles(files, "churn", { cwd: repo, seed: 0 })).toEqual([
+      join(repo, "hot.ts"),
+      join(repo, "old.ts"),
+    ]);
+    expect(orderFiles(files, "recent", { cwd: repo, seed: 0 })[0]).toBe(
+      join(repo, "hot.ts"),
+    );
+  });
+
+  it("should shuffle deterministically for a seed", () => {
+    const files = Array.from({ length: 20 }, (_, i) => `/src/f${i}.ts`);
+    const options = { cwd: repo, seed: 7 };
+    const first = orderFiles(files, "random-seeded", options);
+
+    expect(
+      orderFiles([...files].reverse(), "random-seeded", options),
+    ).toEqual(first);
+    expect(
+      orderFiles(files, "random-seeded", { ...options, seed: 8 }),
+    ).not.toEqual(first);
+  });
+});
+
+describe("annotateBlame", () => {
+  it("should add author and age, and filter by age", () => {
+    const [oldFinding] = annotateBlame(join(repo, "old.ts"), [finding(0)], 1);
+    const [newFinding] = annotateBlame(join(repo, "hot.ts"), [finding(1)], 2);
+
+    expect(oldFinding.blame).toMatchObject({
+      author: "Ada",
+      lastModified: "2020-01-01T00:00:00.000Z",
+    });
+    expect(newFinding.blame?.ageDays).toBe(2);
+    expect(filterByAge([oldFinding, newFinding], 30)).toEqual([newFinding]);
+  });
+
+  it("should blame the finding's own line", () => {
+    const file = join(repo, "pair.ts");
+    const [first, second] = annotateBlame(file, [finding(0), finding(1)], 2);
+
+    expect(first.blame?.author).toBe("Ada");
+    expect(second.blame?.author).toBe("Grace");
+  });
+
+  it("should leave findings unchanged outside git", () => {
+    const outside = mkdtempSync(join(tmpdir(), "lintai-nogit-"));
+    writeFileSync(join(outside, "a.ts"), "x\n");
+
+    expect(annotateBlame(join(outside, "a.ts"), [finding(0)], 1)).toEqual([
+      finding(0),
+    ]);
+    rmSync(outside, { recursive: true, force: true });
+  });
+});
@@ -0,0 +1,68 @@
+import { describe, it, expect } from "vitest";
+import { getLanguageForFile } from "../src/core/languages.js";
+import { buildSystemPrompt } from "../src/llm/prompt-builder.js";
+import { DEFAULT_CONFIG } from "../src/config/defaults.js";
+
+const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`;
+
+describe("getLanguageForFile", () => {
+  it("should detect Dockerfiles by name", () => {
+    expect(getLanguageForFile("/repo/Dockerfile")?.id).toBe("dockerfile");
+    expect(getLanguageForFile("/repo/docker/Dockerfile.prod")?.id).toBe(
+      "dockerfile",
+    );
+    expect(getLanguageForFile("/repo/api.dockerfile")?.id).toBe("dockerfile");
+  });
+
+  it("should not detect files inside a Dockerfile-named directory", () => {
+    expect(getLanguageForFile("/repo/Dockerfile.d/run.sh")?.id).not.toBe(
+      "dockerfile",
+    );
+    expect(getLanguageForFile("/repo/build/x.Dockerfile/main.go")?.id).toBe(
+      "go",
+    );
+    expect(
+      getLanguageForFile("/repo/.github/workflows/ci.yml/notes.yml"),
+    ).toBeUndefined();
+  });
+
+  it("should detect Terraform by extension", () => {
+    expect(getLanguageForFile("/repo/infra/main.tf")?.id).toBe("terraform");
+  });
+
+  it("should detect GitHub workflows by path", () => {
+    expect(getLanguageForFile("/repo/.github/workflows/ci.yml")?.id).toBe(
+      "github-actions",
+    );
+    expect(getLanguageForFile("/repo/workflows/ci.yml")).toBeUndefined();
+  });
+
+  it("should detect Kubernetes manifests by content", () => {
+    expect(getLanguageForFile("/repo/k8s/web.yaml", deployment)?.id).toBe(
+      "kubernetes",
+    );
+    expect(
+      getLanguageForFile("/repo/config.yaml", "port: 8080\n"),
+    ).toBeUndefined();
+    expect(getLanguageForFile("/repo/k8s/web.yaml")).toBeUndefined();
+  });
+
+  it("should prefer a workflow path over content", () => {
+    expect(
+      getLanguageForFile("/repo/.github/workflows/deploy.yaml", deployment)?.id,
+    ).toBe("github-actions");
+  });
+});
+
+describe("IaC prompts", () => {
+  it("should include the profile's instructions", () => {
+    const prompt = buildSystemPrompt(DEFAULT_CONFIG.rules, "github-actions");
+
+    expect(prompt).toContain("GitHub Actions workflow");
+    expect(prompt).toContain("${{ github.event.* }}");
+  });
+});
@@ -0,0 +1,99 @@
+import { describe, it, expect } from "vitest";
+import { documentLocation, uriToPath } from "../src/lsp/uri.js";
+import { getLanguageForLanguageId } from "../src/core/languages.js";
+import { analyze } from "../src/core/analyzer.js";
+import { checkEgressPolicy } from "../src/core/egress-policy.js";
+import { DEFAULT_CONFIG } from "../src/config/defaults.js";
+import { resolveLLMConfig } from "../src/types/config.js";
+
+const remote = resolveLLMConfig(DEFAULT_CONFIG.llm);
+
+describe("uriToPath", () => {
+  it("should decode percent-encoded file URIs", () => {
+    expect(uriToPath("file:///home/me/my%20project/gr%C3%B6%C3%9Fe.ts")).toBe(
+      "/home/me/my project/größe.ts",
+    );
+  });
+
+  it("should return null for other schemes", () => {
+    expect(uriToPath("untitled:Untitled-1")).toBeNull();
+  });
+});
+
+describe("documentLocation", () => {
+  it("should use the path of files on disk", () => {
+    expect(documentLocation("file:///repo/src/a%20b.go", "/repo")).toEqual({
+      filePath: "/repo/src/a b.go",
+      onDisk: true,
+      localOnly: false,
+    });
+  });
+
+  it("should name unsaved buffers under the workspace root", () => {
+    expect(documentLocation("untitled:Untitled-1", "/repo")).toEqual({
+      filePath: "/repo/Untitled-1",
+      onDisk: false,
+      localOnly: true,
+    });
+  });
+
+  it("should keep the workspace path of virtual documents", () => {
+    expect(
+      documentLocation(
+        "git:/repo/src/main.py?%7B%22ref%22%3A%22HEAD%22%7D",
+        "/repo",
+      ),
+    ).toEqual({
+      filePath: "/repo/src/main.py",
+      onDisk: false,
+      localOnly: false,
+    });
+  });
+
+  it("should keep virtual documents outside the workspace local", () => {
+    expect(documentLocation("git:/elsewhere/main.py", "/repo")).toEqual({
+      filePath: "/repo/main.py",
+      onDisk: false,
+      localOnly: true,
+    });
+  });
+
+  it("should apply localOnlyPaths to virtual documents", () => {
+    const { filePath } = documentLocation("git:/repo/secrets/key.py", "/repo");
+    const privacy = {
+      ...DEFAULT_CONFIG.privacy,
+      localOnlyPaths: ["secrets/**"],
+    };
+
+    expect(checkEgressPolicy(filePath, remote, privacy, "/repo")).toMatch(
+      /localOnlyPaths pattern "secrets\/\*\*"/,
+    );
+  });
+
+  it("should not send local-only documents to a remote provider", async () => {
+    const { filePath, localOnly } = documentLocation(
+      "untitled:Untitled-1",
+      "/repo",
+    );
+
+    const result = await analyze({
+      filePath,
+      content: "print('hi')\n",
+      config: DEFAULT_CONFIG,
+      languageId: "python",
+      rootDir: "/repo",
+      localOnly,
+    });
+
+    expect(result.error).toMatch(/not a workspace file/);
+    expect(result.findings).toEqual([]);
+  });
+});
+
+describe("getLanguageForLanguageId", () => {
+  it("should map LSP language identifiers", () => {
+    expect(getLanguageForLanguageId("typescriptreact")?.id).toBe("typescript");
+    expect(getLanguageForLanguageId("go")?.id).toBe("go");
+    expect(getLanguageForLanguageId("plaintext")).toBeUndefined();
+  });
+});
@@ -0,0 +1,88 @@
+import { describe, it, expect } from "vitest";
+import {
+  isNotebook,
+  mapToCell,
+  notebookToDocument,
+} from "../src/core/notebooks.js";
+import type { Finding } from "../src/types/finding.js";
+
+const notebook = JSON.stringify({
+  nbformat: 4,
+  cells: [
+    { cell_type: "markdown", source: ["# Analysis\n"] },
+    {
+      cell_type: "code",
+      source: ["import pandas as pd\n", "df = pd.read_csv('data.csv')\n"],
+      outputs: [{ output_type: "stream", text: ["ignored\n"] }],
+    },
+    { cell_type: "code", source: "for i in range(len(df)):\n    print(i)" },
+  ],
+});
+
+const finding = (startLine: number, endLine = startLine): Finding => ({
+  id: "AI001",
+  title: "Issue",
+  severity: "warning",
+  message: "Something",
+  suggestion: "",
+  category: "practice",
+  confidence: 0.8,
+  range: { startLine, startCharacter: 4, endLine, endCharacter: 9 },
+});
+
+describe("notebookToDocument", () => {
+  it("should join code cells with cell markers and skip outputs", () => {
+    const document = notebookToDocument(notebook);
+
+    expect(document.content).toBe(
+      [
+        "# %% [cell 2]",
+        "import pandas as pd",
+        "df = pd.read_csv('data.csv')",
+        "# %% [cell 3]",
+        "for i in range(len(df)):",
+        "    print(i)",
+      ].join("\n"),
+    );
+    expect(document.cells).toEqual([
+      { cell: 2, startLine: 1, lineCount: 2 },
+      { cell: 3, startLine: 4, lineCount: 2 },
+    ]);
+  });
+
+  it("should reject files that are not notebooks", () => {
+    expect(() => notebookToDocument("{}")).toThrow(/nbformat 4/);
+  });
+
+  it("should recognize notebook paths", () => {
+    expect(isNotebook("/repo/Analysis.IPYNB")).toBe(true);
+    expect(isNotebook("/repo/analysis.py")).toBe(false);
+  });
+});
+
+describe("mapToCell", () => {
+  const document = notebookToDocument(notebook);
+
+  it("should map lines to the cell and its own lines", () => {
+    expect(mapToCell(finding(5), document)).toMatchObject({
+      cell: 3,
+      range: { startLine: 1, startCharacter: 4, endLine: 1, endCharacter: 9 },
+    });
+  });
+
+  it("should move a range on a cell marker into the cell", () => {
+    expect(mapToCell(finding(3, 4), document).range).toEqual({
+      startLine: 0,
+      startCharacter: 0,
+      endLine: 0,
+      endCharacter: 9,
+    });
+  });
+
+  it("should end a range running into the next cell at the cell's end", () => {
+    expect(mapToCell(finding(1, 4), document)).toMatchObject({
+      cell: 2,
+      range: { startLine: 0, endLine: 1, endCharacter: 28 },
+    });
+  });
+});
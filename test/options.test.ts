@@ -0,0 +1,26 @@
+import { describe, it, expect } from "vitest";
+import { parseIntegerOption } from "../src/cli/options.js";
+
+describe("parseIntegerOption", () => {
+  it("should parse whole numbers", () => {
+    expect(parseIntegerOption("30", "--max-age")).toBe(30);
+    expect(parseIntegerOption("0", "--seed")).toBe(0);
+  });
+
+  it("should reject values that are not non-negative integers", () => {
+    expect(() => parseIntegerOption("abc", "--max-age")).toThrow(
+      /Invalid --max-age "abc", expected a non-negative integer/,
+    );
+    expect(() => parseIntegerOption("-1", "--max-age")).toThrow();
+    expect(() => parseIntegerOption("1.5", "--seed")).toThrow();
+    expect(() => parseIntegerOption("", "--seed")).toThrow();
+  });
+
+  it("should reject zero when a positive integer is needed", () => {
+    expect(parseIntegerOption("1", "--max-requests", true)).toBe(1);
+    expect(() => parseIntegerOption("0", "--max-requests", true)).toThrow(
+      /expected a positive integer/,
+    );
+    expect(() => parseIntegerOption("-5", "--max-requests", true)).toThrow();
+  });
+});
@@ -0,0 +1,140 @@
+import { describe, it, expect } from "vitest";
+import {
+  encodeColumn,
+  negotiatePositionEncoding,
+  normalizeRange,
+  splitLines,
+} from "../src/core/positions.js";
+import { findingToDiagnostic } from "../src/core/diagnostics-mapper.js";
+import { formatFinding } from "../src/cli/formatter.js";
+import { parseResponse } from "../src/llm/response-parser.js";
+import type { Finding } from "../src/types/finding.js";
+
+describe("negotiatePositionEncoding", () => {
+  it("should take the first supported encoding the client offers", () => {
+    expect(negotiatePositionEncoding(["utf-8", "utf-16"])).toBe("utf-8");
+    expect(negotiatePositionEncoding(["latin1", "utf-32"])).toBe("utf-32");
+  });
+
+  it("should default to utf-16", () => {
+    expect(negotiatePositionEncoding(undefined)).toBe("utf-16");
+    expect(negotiatePositionEncoding(["latin1"])).toBe("utf-16");
+  });
+});
+
+describe("splitLines", () => {
+  it("should drop CRLF and CR terminators", () => {
+    expect(splitLines("a\r\nb\rc\nd")).toEqual(["a", "b", "c", "d"]);
+  });
+});
+
+describe("encodeColumn", () => {
+  const line = 'const 🎉 = "größe";';
+
+  it("should count code units of the encoding", () => {
+    // Column 8 is after the emoji and the following space
+    expect(encodeColumn(line, 8, "utf-32")).toBe(8);
+    expect(encodeColumn(line, 8, "utf-16")).toBe(9);
+    expect(encodeColumn(line, 8, "utf-8")).toBe(11);
+  });
+
+  it("should count non-Latin letters", () => {
+    // Column 15 is after "größ"
+    expect(encodeColumn(line, 15, "utf-16")).toBe(16);
+    expect(encodeColumn(line, 15, "utf-8")).toBe(20);
+  });
+
+  it("should clamp to the end of the line", () => {
+    expect(encodeColumn("abc", 10, "utf-16")).toBe(3);
+  });
+});
+
+describe("normalizeRange", () => {
+  const lines = splitLines("function f() {\r\n\t\treturn 1;\r\n}\r\n");
+
+  it("should clamp lines and columns to the text", () => {
+    expect(
+      normalizeRange(
+        { startLine: 2, startCharacter: 0, endLine: 9, endCharacter: 40 },
+        lines,
+      ),
+    ).toEqual({ startLine: 2, startCharacter: 0, endLine: 3, endCharacter: 0 });
+  });
+
+  it("should not count the carriage return as part of the line", () => {
+    expect(
+      normalizeRange(
+        { startLine: 0, startCharacter: 0, endLine: 0, endCharacter: 99 },
+        lines,
+      ).endCharacter,
+    ).toBe(14);
+  });
+
+  it("should move a start inside tab indentation to the code", () => {
+    expect(
+      normalizeRange(
+        { startLine: 1, startCharacter: 0, endLine: 1, endCharacter: 0 },
+        lines,
+      ),
+    ).toEqual({
+      startLine: 1,
+      startCharacter: 2,
+      endLine: 1,
+      endCharacter: 11,
+    });
+  });
+});
+
+describe("findingToDiagnostic with document positions", () => {
+  it("should convert columns into the negotiated encoding", () => {
+    const finding: Finding = {
+      id: "AI001",
+      title: "Magic value",
+      severity: "warning",
+      message: "Unexplained literal",
+      suggestion: "",
+      category: "smell",
+      confidence: 0.9,
+      range: { startLine: 0, startCharacter: 6, endLine: 0, endCharacter: 8 },
+    };
+    const document = { lines: ["😀 x = 42;"], encoding: "utf-16" as const };
+
+    const diagnostic = findingToDiagnostic(
+      finding,
+      { highConfidenceThreshold: 0.8, mediumConfidenceThreshold: 0.5 },
+      1,
+      document,
+    );
+
+    expect(diagnostic.range.start).toEqual({ line: 0, character: 7 });
+    expect(diagnostic.range.end).toEqual({ line: 0, character: 9 });
+  });
+});
+
+describe("model line numbers", () => {
+  it("should print the line the model reported", () => {
+    const lines = ["function f() {", "  const a = 1;", "    return a;", "}"];
+    // The model counts lines from 1: line 2 is "  const a = 1;"
+    const response = JSON.stringify([
+      {
+        id: "AI001",
+        title: "Needless temporary",
+        severity: "info",
+        message: "a is returned right away",
+        suggestion: "Return 1",
+        category: "smell",
+        confidence: 0.8,
+        range: { startLine: 2, startCharacter: 0, endLine: 2, endCharacter: 0 },
+      },
+    ]);
+
+    const [finding] = parseResponse(response).findings;
+    const range = finding.range && normalizeRange(finding.range, lines);
+    const output = formatFinding({ ...finding, range }, "src/f.js", {
+      useColor: false,
+    });
+
+    // Column 3 is the first code character of line 2, not of line 3
+    expect(output.split("\n")[0]).toContain("src/f.js:2:3");
+  });
+});
@@ -0,0 +1,94 @@
+import { describe, it, expect, beforeAll, afterAll } from "vitest";
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { join } from "node:path";
+import { tmpdir } from "node:os";
+import {
+  detectProjectFacts,
+  getLanguageForFile,
+  sqlDialectFacts,
+  versionAtLeast,
+} from "../src/core/languages.js";
+import { DEFAULT_CONFIG } from "../src/config/defaults.js";
+import { buildSystemPrompt } from "../src/llm/prompt-builder.js";
+
+describe("detectProjectFacts", () => {
+  let root: string;
+
+  beforeAll(() => {
+    root = mkdtempSync(join(tmpdir(), "lintai-facts-"));
+    mkdirSync(join(root, "svc", "internal"), { recursive: true });
+    writeFileSync(join(root, "svc", "go.mod"), "module svc\n\ngo 1.17\n");
+    writeFileSync(
+      join(root, "tsconfig.json"),
+      `{
+  // comments and trailing commas are allowed
+  "compilerOptions": { "strict": true, "target": "ES5", },
+}`,
+    );
+  });
+
+  afterAll(() => {
+    rmSync(root, { recursive: true, force: true });
+  });
+
+  it("should read the Go version from the nearest go.mod", () => {
+    const facts = detectProjectFacts(
+      join(root, "svc", "internal", "db.go"),
+      "go",
+      root,
+    );
+
+    expect(facts[0]).toContain("Go version: 1.17");
+    expect(facts.some((f) => f.includes("interface{} with any"))).toBe(true);
+  });
+
+  it("should read strict and target from tsconfig.json", () => {
+    const facts = detectProjectFacts("src/app.ts", "typescript", root);
+
+    expect(facts.some((f) => f.startsWith("Strict mode is on"))).toBe(true);
+    expect(facts).toContain("Compile target: ES5");
+    expect(facts.some((f) => f.includes("optional chaining"))).toBe(true);
+  });
+
+  it("should return nothing without a project file", () => {
+    expect(detectProjectFacts("lib/main.py", "python", root)).toEqual([]);
+  });
+});
+
+describe("versionAtLeast", () => {
+  it("should compare dotted versions numerically", () => {
+    expect(versionAtLeast("1.21.3", "1.21")).toBe(true);
+    expect(versionAtLeast("1.9", "1.18")).toBe(false);
+    expect(versionAtLeast("3.10", "3.9")).toBe(true);
+  });
+});
+
+describe("sqlDialectFacts", () => {
+  it("should describe the configured dialect", () => {
+    const facts = sqlDialectFacts("postgres");
+
+    expect(facts[0]).toBe("SQL dialect: PostgreSQL");
+    expect(facts.some((f) => f.includes("CONCURRENTLY"))).toBe(true);
+  });
+
+  it("should add nothing without a dialect", () => {
+    expect(sqlDialectFacts(undefined)).toEqual([]);
+  });
+
+  it("should detect .sql files as SQL", () => {
+    expect(getLanguageForFile("/repo/db/migrate/001_users.up.sql")?.id).toBe(
+      "sql",
+    );
+  });
+
+  it("should review migrations with the dialect's locking rules", () => {
+    const prompt = buildSystemPrompt(DEFAULT_CONFIG.rules, "sql", {
+      projectFacts: sqlDialectFacts("mysql"),
+    });
+
+    expect(prompt).toContain("db/migrate/");
+    expect(prompt).toContain("Non-reversible migrations");
+    expect(prompt).toContain("SQL dialect: MySQL");
+    expect(prompt).toContain("DDL is not transactional");
+  });
+});
@@ -0,0 +1,55 @@
+import { describe, it, expect } from "vitest";
+import {
+  buildSystemPrompt,
+  buildUnitUserPrompt,
+  buildUserPrompt,
+} from "../src/llm/prompt-builder.js";
+import { DEFAULT_CONFIG } from "../src/config/defaults.js";
+import { FindingSchema } from "../src/types/finding.js";
+
+describe("buildSystemPrompt", () => {
+  it("should leave documentation checks out by default", () => {
+    const prompt = buildSystemPrompt(DEFAULT_CONFIG.rules, "go");
+
+    expect(prompt).not.toContain("DOCUMENTATION");
+  });
+
+  it("should ask for documentation findings when enabled", () => {
+    const prompt = buildSystemPrompt(
+      { ...DEFAULT_CONFIG.rules, documentation: true },
+      "go",
+    );
+
+    expect(prompt).toContain('- DOCUMENTATION (category "documentation")');
+    expect(prompt).toContain("exported Go identifiers");
+  });
+});
+
+describe("documentation findings", () => {
+  it("should be accepted by the finding schema", () => {
+    const finding = FindingSchema.safeParse({
+      id: "AI001",
+      title: "Comment contradicts code",
+      severity: "info",
+      message: "The comment says the list is sorted, but it is not",
+      suggestion: "Sort the list or fix the comment",
+      category: "documentation",
+      confidence: 0.7,
+    });
+
+    expect(finding.success).toBe(true);
+  });
+
+  it("should be offered as a category in the user prompts", () => {
+    const prompts = [
+      buildUserPrompt("src/a.ts", "export {};\n", "ts"),
+      buildUnitUserPrompt([{ filePath: "src/a.ts", content: "export {};\n" }]),
+    ];
+
+    for (const prompt of prompts) {
+      const categories = prompt.match(/^Categories: (.*)$/m)?.[1]?.split(", ");
+      expect(categories).toContain("documentation");
+      expect(categories).toContain("untested");
+    }
+  });
+});
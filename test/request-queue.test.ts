@@ -0,0 +1,25 @@
+import { describe, it, expect } from "vitest";
+import { RequestQueue } from "../src/llm/request-queue.js";
+
+describe("RequestQueue", () => {
+  it("should abort in-flight and pending requests on cancelAll", async () => {
+    const queue = new RequestQueue();
+    let inFlightSignal: AbortSignal | undefined;
+
+    const inFlight = queue.enqueue(
+      "a.ts",
+      (signal) =>
+        new Promise<string>((resolve) => {
+          inFlightSignal = signal;
+          setTimeout(() => resolve("done"), 1000);
+        }),
+    );
+    const pending = queue.enqueue("b.ts", async () => "done");
+
+    queue.cancelAll();
+
+    await expect(inFlight).rejects.toThrow(/cancelled/);
+    await expect(pending).rejects.toThrow(/cancelled/);
+    expect(inFlightSignal?.aborted).toBe(true);
+  });
+});
@@ -0,0 +1,128 @@
+import { describe, it, expect, beforeAll, afterAll } from "vitest";
+import { execFileSync } from "node:child_process";
+import {
+  existsSync,
+  mkdirSync,
+  mkdtempSync,
+  rmSync,
+  writeFileSync,
+} from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { loadRevisionFiles } from "../src/cli/revisions.js";
+
+let repo: string;
+const commits: string[] = [];
+
+function git(args: string[]): string {
+  return execFileSync(
+    "git",
+    ["-c", "user.name=Ada", "-c", "user.email=ada@example.com", ...args],
+    { cwd: repo, encoding: "utf-8", stdio: ["ignore", "pipe", "ignore"] },
+  );
+}
+
+function commit(files: Record<string, string>) {
+  for (const [file, content] of Object.entries(files)) {
+    mkdirSync(join(repo, file, ".."), { recursive: true });
+    writeFileSync(join(repo, file), content);
+    git(["add", file]);
+  }
+  git(["commit", "-q", "-m", "update"]);
+  commits.push(git(["rev-parse", "HEAD"]).trim());
+}
+
+beforeAll(() => {
+  repo = mkdtempSync(join(tmpdir(), "lintai-rev-"));
+  git(["init", "-q"]);
+  commit({ "src/a.ts": "export const a = 1;\n", "README.md": "# repo\n" });
+  commit({ "src/a.ts": "export const a = 2;\n", "src/b.ts": "let b;\n" });
+  commit({ "src/a.ts": "export const a = 3;\n" });
+  // A path git quotes unless asked for NUL-separated output
+  commit({ "src/spaced näme.ts": "export const c = 1;\n" });
+  // Work tree changes are not part of any revision
+  writeFileSync(join(repo, "src/a.ts"), "uncommitted\n");
+});
+
+afterAll(() => {
+  rmSync(repo, { recursive: true, force: true });
+});
+
+describe("loadRevisionFiles", () => {
+  it("should read files as of a commit", () => {
+    const source = loadRevisionFiles({
+      rev: commits[1],
+      paths: ["."],
+      extensions: ["ts"],
+      cwd: repo,
+    });
+
+    expect(Array.from(source.files.keys()).sort()).toEqual([
+      join(repo, "src/a.ts"),
+      join(repo, "src/b.ts"),
+    ]);
+    expect(source.read(join(repo, "src/a.ts"))).toBe("export const a = 2;\n");
+  });
+
+  it("should list every version changed in a range", () => {
+    const source = loadRevisionFiles({
+      range: `${commits[0]}..${commits[2]}`,
+      paths: ["src"],
+      extensions: ["ts"],
+      cwd: repo,
+    });
+    const name = (file: string, i: number) =>
+      `${join(repo, file)}@${commits[i].slice(0, 7)}`;
+
+    expect(Array.from(source.files.keys())).toEqual([
+      name("src/a.ts", 2),
+      name("src/a.ts", 1),
+      name("src/b.ts", 1),
+    ]);
+    expect(source.read(name("src/a.ts", 1))).toBe("export const a = 2;\n");
+    expect(source.files.get(name("src/b.ts", 1))?.path).toBe(
+      join(repo, "src/b.ts"),
+    );
+  });
+
+  it("should list paths with spaces and non-ASCII characters", () => {
+    const options = { paths: ["src"], extensions: ["ts"], cwd: repo };
+    const file = join(repo, "src/spaced näme.ts");
+
+    const tree = loadRevisionFiles({ ...options, rev: commits[3] });
+    const range = loadRevisionFiles({
+      ...options,
+      range: `${commits[2]}..${commits[3]}`,
+    });
+
+    expect(tree.files.has(file)).toBe(true);
+    expect(Array.from(range.files.values()).map((f) => f.path)).toEqual([
+      file,
+    ]);
+  });
+
+  it("should not read options from --range", () => {
+    const output = join(repo, "leak.txt");
+
+    expect(() =>
+      loadRevisionFiles({
+        range: `--output=${output}`,
+        paths: ["."],
+        extensions: ["ts"],
+        cwd: repo,
+      }),
+    ).toThrow();
+    expect(existsSync(output)).toBe(false);
+  });
+
+  it("should reject unknown revisions", () => {
+    expect(() =>
+      loadRevisionFiles({
+        rev: "no-such-branch",
+        paths: ["."],
+        extensions: ["ts"],
+        cwd: repo,
+      }),
+    ).toThrow();
+  });
+});
@@ -0,0 +1,134 @@
+import { describe, it, expect } from "vitest";
+import { parseShard, selectShard, shardOf } from "../src/cli/shard.js";
+import { mergeReports } from "../src/cli/merge.js";
+import { formatJSON, type JSONOutput } from "../src/cli/formatter.js";
+import type { AnalysisResult } from "../src/core/analyzer.js";
+import type { Finding } from "../src/types/finding.js";
+
+const files = Array.from({ length: 50 }, (_, i) => `/repo/src/file${i}.ts`);
+
+describe("parseShard", () => {
+  it("should parse i/n", () => {
+    expect(parseShard("2/4")).toEqual({ index: 2, count: 4 });
+  });
+
+  it("should reject invalid shards", () => {
+    expect(() => parseShard("0/4")).toThrow(/between 1 and n/);
+    expect(() => parseShard("5/4")).toThrow(/between 1 and n/);
+    expect(() => parseShard("2")).toThrow(/expected i\/n/);
+  });
+});
+
+describe("selectShard", () => {
+  it("should split files into disjoint shards covering all files", () => {
+    const shards = [1, 2, 3].map((index) =>
+      selectShard(files, { index, count: 3 }, "/repo"),
+    );
+
+    expect(shards.flat().sort()).toEqual([...files].sort());
+    expect(shards.every((shard) => shard.length > 0)).toBe(true);
+  });
+
+  it("should not move files when others are added", () => {
+    const before = files.map((f) => shardOf(f, 4, "/repo"));
+    const more = [...files, "/repo/src/new.ts", "/repo/lib/other.ts"];
+
+    expect(
+      more.slice(0, files.length).map((f) => shardOf(f, 4, "/repo")),
+    ).toEqual(before);
+    expect(
+      selectShard(more, { index: 1, count: 4 }, "/repo").filter((f) =>
+        files.includes(f),
+      ),
+    ).toEqual(selectShard(files, { index: 1, count: 4 }, "/repo"));
+  });
+});
+
+describe("mergeReports", () => {
+  const finding = (severity: "error" | "warning"): Finding => ({
+    id: "AI001",
+    title: "Issue",
+    severity,
+    message: "Something",
+    suggestion: "",
+    category: "smell",
+    confidence: 0.8,
+  });
+
+  const report = (
+    index: number,
+    path: string,
+    findings: Finding[],
+  ): JSONOutput => ({
+    files: [{ path, findings }],
+    summary: { totalFiles: 1, totalFindings: 0, bySeverity: {} },
+    shard: { index, count: 3 },
+  });
+
+  it("should combine files and recompute the summary", () => {
+    const { merged, warnings } = mergeReports([
+      report(1, "/repo/a.ts", [finding("error"), finding("warning")]),
+      report(2, "/repo/b.ts", [finding("warning")]),
+      report(3, "/repo/c.ts", []),
+    ]);
+
+    expect(merged.files.map((f) => f.path)).toEqual([
+      "/repo/a.ts",
+      "/repo/b.ts",
+      "/repo/c.ts",
+    ]);
+    expect(merged.summary).toEqual({
+      totalFiles: 3,
+      totalFindings: 3,
+      bySeverity: { error: 1, warning: 2 },
+    });
+    expect(warnings).toEqual([]);
+  });
+
+  it("should warn about missing shards and duplicate files", () => {
+    const { merged, warnings } = mergeReports([
+      report(1, "/repo/a.ts", [finding("error")]),
+      report(3, "/repo/a.ts", []),
+    ]);
+
+    expect(merged.summary.totalFindings).toBe(0);
+    expect(warnings).toEqual([
+      "/repo/a.ts is in more than one report",
+      "Missing shard(s) 2 of 3",
+    ]);
+  });
+});
+
+describe("shard reports", () => {
+  const results = (root: string) =>
+    new Map<string, AnalysisResult>([
+      [`${root}/src/a.ts`, { findings: [], cached: false }],
+    ]);
+
+  it("should write paths relative to the repository root", () => {
+    const report: JSONOutput = JSON.parse(
+      formatJSON(results("/runner-1/repo"), {
+        shard: { index: 1, count: 2 },
+        stopped: { reason: "time-budget", skipped: ["/runner-1/repo/b.ts"] },
+        rootDir: "/runner-1/repo",
+      }),
+    );
+
+    expect(report.files.map((f) => f.path)).toEqual(["src/a.ts"]);
+    expect(report.skipped).toEqual(["b.ts"]);
+  });
+
+  it("should merge the same file from different checkouts", () => {
+    const reports = ["/runner-1/repo", "/runner-2/work/repo"].map(
+      (root, i): JSONOutput => ({
+        ...JSON.parse(formatJSON(results(root), { rootDir: root })),
+        shard: { index: i + 1, count: 2 },
+      }),
+    );
+
+    const { merged, warnings } = mergeReports(reports);
+
+    expect(merged.files.map((f) => f.path)).toEqual(["src/a.ts"]);
+    expect(warnings).toEqual(["src/a.ts is in more than one report"]);
+  });
+});
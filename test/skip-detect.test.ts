@@ -0,0 +1,79 @@
+import { describe, it, expect } from "vitest";
+import { detectSkipReason } from "../src/core/skip-detect.js";
+
+const root = "/repo";
+
+describe("detectSkipReason", () => {
+  it("should skip Go-style generated files", () => {
+    const content = `// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: api.proto
+
+package api
+`;
+
+    expect(detectSkipReason("/repo/api/api.pb.go", content, root)).toMatch(
+      /DO NOT EDIT/,
+    );
+  });
+
+  it("should skip files with an @generated marker in the header", () => {
+    const content = "/**\n * @generated by relay-compiler\n */\nexport {};\n";
+
+    expect(detectSkipReason("/repo/src/query.ts", content, root)).toBe(
+      "generated code (@generated marker)",
+    );
+  });
+
+  it("should not skip code that only mentions the markers", () => {
+    const header = "\n".repeat(30);
+    const content = `${header}const marker = "// Code generated x DO NOT EDIT.";\n`;
+
+    expect(detectSkipReason("/repo/src/gen.ts", content, root)).toBeNull();
+  });
+
+  it("should skip lockfiles and vendored code", () => {
+    expect(detectSkipReason("/repo/web/pnpm-lock.yaml", "", root)).toBe(
+      "lockfile",
+    );
+    expect(detectSkipReason("/repo/vendor/github.com/x/y.go", "", root)).toBe(
+      "vendored dependency",
+    );
+  });
+
+  it("should skip minified code", () => {
+    const minified = "var a=function(b){return b+1};".repeat(100);
+
+    expect(detectSkipReason("/repo/static/app.js", minified, root)).toBe(
+      "minified code",
+    );
+    expect(detectSkipReason("/repo/static/app.min.js", "x", root)).toBe(
+      "minified code",
+    );
+  });
+
+  it("should not mistake notebook image outputs for minified code", () => {
+    const notebook = JSON.stringify({
+      nbformat: 4,
+      cells: [
+        {
+          cell_type: "code",
+          source: ["import matplotlib.pyplot as plt\n", "plt.plot([1])\n"],
+          outputs: [
+            {
+              output_type: "display_data",
+              data: { "image/png": "iVBORw0KGgo".repeat(500) },
+            },
+          ],
+        },
+      ],
+    });
+
+    expect(detectSkipReason("/repo/analysis.ipynb", notebook, root)).toBeNull();
+  });
+
+  it("should analyze ordinary code with long lines", () => {
+    const content = `export const message = "${"lorem ipsum ".repeat(100)}";\n`;
+
+    expect(detectSkipReason("/repo/src/text.ts", content, root)).toBeNull();
+  });
+});
@@ -0,0 +1,186 @@
+import { describe, it, expect, beforeEach, afterEach } from "vitest";
+import {
+  RequestBudgetError,
+  sendLLMRequestWithTools,
+  setLLMRequestBudget,
+  type ToolCall,
+  type ToolDefinition,
+} from "../src/llm/client.js";
+import type { ResolvedLLMConfig } from "../src/types/config.js";
+
+const config: ResolvedLLMConfig = {
+  provider: "openai",
+  baseUrl: "https://api.openai.com/v1",
+  model: "gpt-4o-mini",
+  apiKey: "sk-test",
+  timeout: 30000,
+  maxTokens: 2048,
+};
+
+const tools: ToolDefinition[] = [
+  { name: "read_file", description: "Read a file", parameters: {} },
+];
+
+const toolCall = (id: string, path: string) => ({
+  id,
+  type: "function",
+  function: { name: "read_file", arguments: JSON.stringify({ path }) },
+});
+
+interface RequestBody {
+  tool_choice: string;
+  messages: Array<{ role: string; content: string | null }>;
+}
+
+describe("runToolLoop", () => {
+  const originalFetch = globalThis.fetch;
+  let bodies: RequestBody[];
+
+  // Answer each request with the next canned assistant message
+  const respondWith = (messages: Array<Record<string, unknown>>) => {
+    bodies = [];
+    globalThis.fetch = (async (_url: string, init: RequestInit) => {
+      bodies.push(JSON.parse(init.body as string));
+      const message = messages[bodies.length - 1] ?? { content: "[]" };
+      return new Response(
+        JSON.stringify({
+          choices: [{ message }],
+          usage: { prompt_tokens: 10, completion_tokens: 5, total_tokens: 15 },
+        }),
+        { status: 200 },
+      );
+    }) as typeof fetch;
+  };
+
+  beforeEach(() => {
+    bodies = [];
+  });
+
+  afterEach(() => {
+    globalThis.fetch = originalFetch;
+    setLLMRequestBudget(undefined);
+  });
+
+  it("should run tool calls until the model answers", async () => {
+    respondWith([
+      { content: null, tool_calls: [toolCall("c1", "a.ts")] },
+      { content: '[{"id":"AI001"}]' },
+    ]);
+    const executed: ToolCall[] = [];
+
+    const response = await sendLLMRequestWithTools(
+      {
+        systemPrompt: "system",
+        userPrompt: "user",
+        config,
+        rateLimitEnabled: false,
+        requestId: "loop-1",
+      },
+      {
+        tools,
+        maxCalls: 4,
+        execute: async (call) => {
+          executed.push(call);
+          return { content: "1: export const a = 1;" };
+        },
+      },
+    );
+
+    expect(response.content).toBe('[{"id":"AI001"}]');
+    expect(response.usage?.totalTokens).toBe(30);
+    expect(executed.map((c) => c.input)).toEqual([{ path: "a.ts" }]);
+    expect(bodies[1].messages.at(-1)).toMatchObject({
+      role: "tool",
+      tool_call_id: "c1",
+      content: "1: export const a = 1;",
+    });
+  });
+
+  it("should stop calling tools when the call budget is spent", async () => {
+    respondWith([
+      {
+        content: null,
+        tool_calls: [toolCall("c1", "a.ts"), toolCall("c2", "b.ts")],
+      },
+      { content: "[]" },
+    ]);
+    let calls = 0;
+
+    await sendLLMRequestWithTools(
+      {
+        systemPrompt: "system",
+        userPrompt: "user",
+        config,
+        rateLimitEnabled: false,
+        requestId: "loop-2",
+      },
+      {
+        tools,
+        maxCalls: 1,
+        execute: async () => {
+          calls++;
+          return { content: "ok" };
+        },
+      },
+    );
+
+    expect(calls).toBe(1);
+    expect(bodies).toHaveLength(2);
+    expect(bodies[1].tool_choice).toBe("none");
+    expect(bodies[1].messages.at(-1)?.content).toMatch(/budget exhausted/);
+  });
+
+  it("should pass tool errors back to the model", async () => {
+    respondWith([
+      { content: null, tool_calls: [toolCall("c1", "a.ts")] },
+      { content: "[]" },
+    ]);
+
+    await sendLLMRequestWithTools(
+      {
+        systemPrompt: "system",
+        userPrompt: "user",
+        config,
+        rateLimitEnabled: false,
+        requestId: "loop-3",
+      },
+      {
+        tools,
+        maxCalls: 2,
+        execute: async () => {
+          throw new Error("disk on fire");
+        },
+      },
+    );
+
+    expect(bodies[1].messages.at(-1)?.content).toBe("Error: disk on fire");
+  });
+
+  it("should refuse tool turns past the request budget", async () => {
+    respondWith(
+      Array.from({ length: 5 }, (_, i) => ({
+        content: null,
+        tool_calls: [toolCall(`c${i}`, "a.ts")],
+      })),
+    );
+    setLLMRequestBudget(2);
+
+    const error = await sendLLMRequestWithTools(
+      {
+        systemPrompt: "system",
+        userPrompt: "user",
+        config,
+        rateLimitEnabled: false,
+        requestId: "loop-4",
+      },
+      {
+        tools,
+        maxCalls: 10,
+        execute: async () => ({ content: "ok" }),
+      },
+    ).catch((e: unknown) => e);
+
+    expect(error instanceof RequestBudgetError).toBe(true);
+    expect(bodies).toHaveLength(2);
+  });
+});
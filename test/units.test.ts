@@ -0,0 +1,66 @@
+import { describe, it, expect } from "vitest";
+import { groupIntoUnits, matchUnitFile } from "../src/core/units.js";
+
+describe("groupIntoUnits", () => {
+  it("should group files by directory and language", () => {
+    const units = groupIntoUnits(
+      ["pkg/a/b.go", "pkg/b/x.go", "pkg/a/a.go", "pkg/a/index.ts"],
+      1000,
+      () => 10,
+    );
+
+    expect(units).toEqual([
+      ["pkg/a/a.go", "pkg/a/b.go"],
+      ["pkg/b/x.go"],
+      ["pkg/a/index.ts"],
+    ]);
+  });
+
+  it("should split groups that exceed the token budget", () => {
+    const units = groupIntoUnits(
+      ["pkg/a.go", "pkg/b.go", "pkg/c.go"],
+      25,
+      () => 10,
+    );
+
+    expect(units).toEqual([["pkg/a.go", "pkg/b.go"], ["pkg/c.go"]]);
+  });
+
+  it("should keep versions from different commits in separate units", () => {
+    const sourceOf = (name: string) => {
+      const [path = "", commit] = name.split("@");
+      return { path, commit };
+    };
+    const units = groupIntoUnits(
+      ["src/a.ts@abc1234", "src/b.ts@abc1234", "src/a.ts@def5678"],
+      1000,
+      () => 10,
+      sourceOf,
+    );
+
+    expect(units).toEqual([
+      ["src/a.ts@abc1234", "src/b.ts@abc1234"],
+      ["src/a.ts@def5678"],
+    ]);
+  });
+});
+
+describe("matchUnitFile", () => {
+  const files = ["pkg/server/handler.go", "pkg/server/routes.go"];
+
+  it("should match exact, suffix and basename paths", () => {
+    expect(matchUnitFile("pkg/server/routes.go", files)).toBe(
+      "pkg/server/routes.go",
+    );
+    expect(matchUnitFile("./server/handler.go", files)).toBe(
+      "pkg/server/handler.go",
+    );
+    expect(matchUnitFile("handler.go", files)).toBe("pkg/server/handler.go");
+  });
+
+  it("should not guess for unknown or missing paths", () => {
+    expect(matchUnitFile("main.go", files)).toBeUndefined();
+    expect(matchUnitFile(undefined, files)).toBeUndefined();
+    expect(matchUnitFile(undefined, ["main.go"])).toBe("main.go");
+  });
+});
@@ -0,0 +1,84 @@
+import { describe, it, expect } from "vitest";
+import {
+  applyVerdict,
+  buildExcerpt,
+  parseVerdict,
+} from "../src/core/verifier.js";
+import { buildVerifierUserPrompt } from "../src/llm/prompt-builder.js";
+import type { Finding } from "../src/types/finding.js";
+
+const finding: Finding = {
+  id: "AI001",
+  title: "Unchecked error",
+  severity: "error",
+  message: "The error returned by Close is ignored",
+  suggestion: "Check the error",
+  category: "practice",
+  confidence: 0.9,
+  // Line 10 of the file
+  range: { startLine: 9, startCharacter: 0, endLine: 9, endCharacter: 20 },
+};
+
+describe("parseVerdict", () => {
+  it("should parse a JSON verdict", () => {
+    expect(
+      parseVerdict(
+        '{"valid": false, "confidence": 0.9, "reason": "Handled by defer"}',
+      ),
+    ).toEqual({
+      valid: false,
+      severity: undefined,
+      confidence: 0.9,
+      reason: "Handled by defer",
+    });
+  });
+
+  it("should accept yes/no strings and wrapped JSON", () => {
+    const verdict = parseVerdict(
+      'Sure:\n```json\n{"valid": "yes", "severity": "Warning"}\n```',
+    );
+    expect(verdict?.valid).toBe(true);
+    expect(verdict?.severity).toBe("warning");
+  });
+
+  it("should return null without a verdict", () => {
+    expect(parseVerdict("I think so")).toBeNull();
+    expect(parseVerdict('{"reason": "unclear"}')).toBeNull();
+  });
+});
+
+describe("buildExcerpt", () => {
+  it("should show the finding's line under its own number", () => {
+    const lines = Array.from({ length: 30 }, (_, i) => `line ${i + 1}`);
+    const excerpt = buildExcerpt(lines, finding);
+    const prompt = buildVerifierUserPrompt("a.go", excerpt, finding, "go");
+
+    expect(excerpt.split("\n")).toContain("10: line 10");
+    expect(excerpt.split("\n")[0]).toBe("1: line 1");
+    expect(excerpt.split("\n").at(-1)).toBe("20: line 20");
+    expect(prompt).toContain("Reported finding (lines 10-10)");
+  });
+});
+
+describe("applyVerdict", () => {
+  it("should drop rejected findings", () => {
+    expect(applyVerdict(finding, { valid: false })).toBeNull();
+  });
+
+  it("should lower but never raise severity and confidence", () => {
+    expect(
+      applyVerdict(finding, {
+        valid: true,
+        severity: "warning",
+        confidence: 0.6,
+      }),
+    ).toMatchObject({ severity: "warning", confidence: 0.6 });
+
+    expect(
+      applyVerdict(
+        { ...finding, severity: "info", confidence: 0.5 },
+        { valid: true, severity: "error", confidence: 1 },
+      ),
+    ).toMatchObject({ severity: "info", confidence: 0.5 });
+  });
+});